@@ -7,10 +7,11 @@ package extension_kit
 import (
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // ExtensionError is a generalization over ActionKit and DiscoveryKit error types. They are structurally identical
-// and can be used interchangeably.
+// on the wire and can be used interchangeably.
 type ExtensionError struct {
 	// A human-readable explanation specific to this occurrence of the problem.
 	Detail *string `json:"detail,omitempty"`
@@ -23,6 +24,18 @@ type ExtensionError struct {
 
 	// A URI reference that identifies the problem type.
 	Type *string `json:"type,omitempty"`
+
+	// The HTTP status code used when the error is written as a response. It is not serialized. Zero means
+	// http.StatusInternalServerError, see StatusCode.
+	Status int `json:"-"`
+
+	// RFC 9457 extension members, serialized as additional top-level members. They are kept behind a pointer, so
+	// that ExtensionError remains comparable. See WithExtension and Extensions.
+	extensions *map[string]any
+
+	// The underlying error, if any. It is not serialized, but exposed through Unwrap so that errors.Is and
+	// errors.As work through an ExtensionError.
+	cause error
 }
 
 func (e ExtensionError) Error() string {
@@ -32,16 +45,82 @@ func (e ExtensionError) Error() string {
 	return e.Title
 }
 
-// ToError converts an error to an ExtensionError.
+// Unwrap returns the error the ExtensionError was created from, if any.
+func (e ExtensionError) Unwrap() error {
+	return e.cause
+}
+
+// As makes errors.As find ExtensionError values when asked for a pointer and vice versa, so callers don't need to
+// know in which form the error was returned.
+func (e ExtensionError) As(target any) bool {
+	switch t := target.(type) {
+	case **ExtensionError:
+		*t = &e
+		return true
+	case *ExtensionError:
+		*t = e
+		return true
+	}
+	return false
+}
+
+// StatusCode returns the HTTP status code for the error. When no status has been set, the status of the registered
+// ProblemType matching Type is used, and http.StatusInternalServerError otherwise.
+func (e ExtensionError) StatusCode() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	if e.Type != nil {
+		if problemType, ok := LookupProblemType(*e.Type); ok && problemType.Status != 0 {
+			return problemType.Status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// WithStatus returns a copy of the error with the given HTTP status code.
+func (e ExtensionError) WithStatus(status int) ExtensionError {
+	e.Status = status
+	return e
+}
+
+// ToError converts an error to an ExtensionError. The error is kept as the cause and can be retrieved through
+// errors.Unwrap, errors.Is and errors.As.
 func ToError(title string, err error) ExtensionError {
 	if err != nil {
-		return ExtensionError{Title: title, Detail: new(err.Error())}
+		return ExtensionError{Title: title, Detail: new(err.Error()), cause: err}
 	} else {
 		return ExtensionError{Title: title}
 	}
 }
 
-// WrapError if the error is an ExtensionError, it is returned as is. Otherwise, a new ExtensionError is with the error as title.
+// ToBadRequestError converts an error to an ExtensionError for invalid input (HTTP 400).
+func ToBadRequestError(title string, err error) ExtensionError {
+	return ToError(title, err).WithStatus(http.StatusBadRequest)
+}
+
+// ToNotFoundError converts an error to an ExtensionError for a missing target or resource (HTTP 404).
+func ToNotFoundError(title string, err error) ExtensionError {
+	return ToError(title, err).WithStatus(http.StatusNotFound)
+}
+
+// ToConflictError converts an error to an ExtensionError for a request conflicting with the current state (HTTP 409).
+func ToConflictError(title string, err error) ExtensionError {
+	return ToError(title, err).WithStatus(http.StatusConflict)
+}
+
+// ToUnavailableError converts an error to an ExtensionError for a temporarily unavailable dependency (HTTP 503).
+func ToUnavailableError(title string, err error) ExtensionError {
+	return ToError(title, err).WithStatus(http.StatusServiceUnavailable)
+}
+
+// ToTimeoutError converts an error to an ExtensionError for an operation that did not complete in time (HTTP 504).
+func ToTimeoutError(title string, err error) ExtensionError {
+	return ToError(title, err).WithStatus(http.StatusGatewayTimeout)
+}
+
+// WrapError if the error is or wraps an ExtensionError (as value or pointer, anywhere in a wrapped or joined chain),
+// it is returned as is. Otherwise, a new ExtensionError is with the error as title.
 func WrapError(err error) *ExtensionError {
 	if err == nil {
 		return nil
@@ -50,5 +129,5 @@ func WrapError(err error) *ExtensionError {
 	if errors.As(err, &extErr) {
 		return extErr
 	}
-	return &ExtensionError{Title: err.Error()}
+	return &ExtensionError{Title: err.Error(), cause: err}
 }
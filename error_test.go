@@ -4,7 +4,9 @@
 package extension_kit
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -21,13 +23,28 @@ func TestWrapError(t *testing.T) {
 		{
 			name: "error",
 			arg:  errors.New("some error"),
-			want: &ExtensionError{Title: "some error"},
+			want: &ExtensionError{Title: "some error", cause: errors.New("some error")},
 		},
 		{
 			name: "ExtensionError",
 			arg:  &ExtensionError{Title: "ext error"},
 			want: &ExtensionError{Title: "ext error"},
 		},
+		{
+			name: "ExtensionError value",
+			arg:  ExtensionError{Title: "ext error"},
+			want: &ExtensionError{Title: "ext error"},
+		},
+		{
+			name: "wrapped ExtensionError value",
+			arg:  fmt.Errorf("context: %w", ExtensionError{Title: "ext error"}),
+			want: &ExtensionError{Title: "ext error"},
+		},
+		{
+			name: "joined ExtensionError pointer",
+			arg:  errors.Join(errors.New("other"), &ExtensionError{Title: "ext error"}),
+			want: &ExtensionError{Title: "ext error"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -54,7 +71,7 @@ func TestToError(t *testing.T) {
 			name:  "some error",
 			title: "some title",
 			err:   errors.New("some error"),
-			want:  ExtensionError{Title: "some title", Detail: new("some error")},
+			want:  ExtensionError{Title: "some title", Detail: new("some error"), cause: errors.New("some error")},
 		},
 	}
 	for _, tt := range tests {
@@ -65,3 +82,78 @@ func TestToError(t *testing.T) {
 		})
 	}
 }
+
+func TestToErrorKeepsCause(t *testing.T) {
+	cause := errors.New("some error")
+	err := error(ToError("some title", fmt.Errorf("context: %w", cause)))
+
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is() = false, want true")
+	}
+
+	var extErr *ExtensionError
+	if !errors.As(err, &extErr) || extErr.Title != "some title" {
+		t.Errorf("errors.As() = %v, want ExtensionError with title 'some title'", extErr)
+	}
+
+	var extErrValue ExtensionError
+	if !errors.As(fmt.Errorf("outer: %w", err), &extErrValue) || extErrValue.Title != "some title" {
+		t.Errorf("errors.As() = %v, want ExtensionError with title 'some title'", extErrValue)
+	}
+
+	b, _ := json.Marshal(err)
+	if got, want := string(b), `{"detail":"context: some error","title":"some title"}`; got != want {
+		t.Errorf("json.Marshal() = %v, want %v", got, want)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ExtensionError
+		want int
+	}{
+		{
+			name: "default",
+			err:  ToError("some title", nil),
+			want: 500,
+		},
+		{
+			name: "bad request",
+			err:  ToBadRequestError("some title", errors.New("some error")),
+			want: 400,
+		},
+		{
+			name: "not found",
+			err:  ToNotFoundError("some title", nil),
+			want: 404,
+		},
+		{
+			name: "conflict",
+			err:  ToConflictError("some title", nil),
+			want: 409,
+		},
+		{
+			name: "unavailable",
+			err:  ToUnavailableError("some title", nil),
+			want: 503,
+		},
+		{
+			name: "timeout",
+			err:  ToTimeoutError("some title", nil),
+			want: 504,
+		},
+		{
+			name: "explicit status",
+			err:  ToError("some title", nil).WithStatus(422),
+			want: 422,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.StatusCode(); got != tt.want {
+				t.Errorf("StatusCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
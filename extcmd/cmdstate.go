@@ -4,86 +4,287 @@
 package extcmd
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type CmdState struct {
-	Id                  string
-	Cmd                 *exec.Cmd
-	exitCode            atomic.Int32
-	mu                  *sync.Mutex
-	out                 *bytes.Buffer
-	lastPartialLineRead string
-}
-
-// Wait blocks until the command exits and records its exit code. It must be called
-// exactly once — typically as `go cmdState.Wait()` right after the command is started.
-// Wait is the sole reader of Cmd.ProcessState, so concurrent callers must obtain the
-// exit code via ExitCode rather than reading Cmd.ProcessState directly; doing the
-// latter races this method. The error returned by exec.Cmd.Wait is passed through for
-// logging.
+	Id       string
+	Cmd      *exec.Cmd
+	exitCode atomic.Int32
+	mu       *sync.Mutex
+	out      *outputBuffer
+
+	classifiers []Classifier
+	parsers     []LineParser
+	stderrLevel string
+	stopSignal  os.Signal
+
+	idleTimeout     time.Duration
+	stopGracePeriod time.Duration
+	reapTimer       *time.Timer
+	lastPolled      atomic.Int64
+
+	resources resources
+	// process is published by Start, so that Stop can be called concurrently.
+	process atomic.Pointer[os.Process]
+	// runnerProcess is published by Start instead of process if the command was started through a Runner.
+	runnerProcess atomic.Pointer[Process]
+	runnerStatus  ExitStatus
+	startTime     time.Time
+	// startTicks identifies the process along with its PID, see processStartTicks.
+	startTicks uint64
+	// rehydrated is set for states restored from a previous run of the extension, see EnablePersistence.
+	rehydrated bool
+
+	// stopRequested is set once Stop signaled the command.
+	stopRequested atomic.Bool
+	// reaped is set before the process of the command is reaped. Its process group must not be signaled afterward, as
+	// the PID may have been reused.
+	reapMu sync.Mutex
+	reaped bool
+
+	waitOnce   sync.Once
+	waitErr    error
+	exitStatus ExitStatus
+	done       chan struct{}
+}
+
+// Start starts the command in a process group of its own and waits for it in the background. Use Wait to obtain
+// the result and Stop to stop the command and its child processes. The resource limits configured through Options are
+// applied, if they cannot be, the command is killed and an error is returned. If a Runner was set through SetRunner, the
+// command is started through it instead.
+func (cs *CmdState) Start() error {
+	if cs.started() {
+		return errors.New("command already started")
+	}
+	if r := currentRunner(); r != nil {
+		return cs.startWith(r)
+	}
+	prepareProcessGroup(cs.Cmd)
+	cs.startTime = time.Now()
+	if err := cs.resources.start(cs.Cmd, "extcmd-"+cs.Id); err != nil {
+		if cs.Cmd.Process != nil {
+			// The resource limits could not be applied.
+			_ = cs.Cmd.Process.Kill()
+			_ = cs.Wait()
+		}
+		return err
+	}
+	cs.startTicks, _ = processStartTicks(cs.Cmd.Process.Pid)
+	cs.persist()
+	cs.process.Store(cs.Cmd.Process)
+	go func() { _ = cs.Wait() }()
+	return nil
+}
+
+// startedProcess returns the process of the command, or nil if it has not been started.
+func (cs *CmdState) startedProcess() *os.Process {
+	if p := cs.process.Load(); p != nil {
+		return p
+	}
+	// Started by the caller.
+	return cs.Cmd.Process
+}
+
+// started reports whether the command has been started, through Start, a Runner or by the caller.
+func (cs *CmdState) started() bool {
+	return cs.runnerProcess.Load() != nil || cs.startedProcess() != nil
+}
+
+// Wait blocks until the command exits and records its exit code. When the command was not
+// started through Start, it must be called — typically as `go cmdState.Wait()` right after
+// the command is started. It may be called multiple times and concurrently, all callers
+// return once the command exited. Wait is the sole reader of Cmd.ProcessState, so
+// concurrent callers must obtain the exit code via ExitCode rather than reading
+// Cmd.ProcessState directly; doing the latter races this method. The error returned by
+// exec.Cmd.Wait is passed through for logging.
 func (cs *CmdState) Wait() error {
-	err := cs.Cmd.Wait()
-	cs.exitCode.Store(int32(cs.Cmd.ProcessState.ExitCode()))
-	return err
+	cs.waitOnce.Do(func() {
+		if p := cs.runnerProcess.Load(); p != nil {
+			cs.waitRunner(*p)
+		} else if cs.rehydrated {
+			cs.waitRehydrated()
+		} else {
+			exited := cs.Cmd.Process != nil && waitExited(cs.Cmd.Process.Pid)
+			if exited {
+				cs.markReaped()
+			}
+			cs.waitErr = cs.Cmd.Wait()
+			if !exited {
+				cs.markReaped()
+			}
+			if cs.Cmd.ProcessState != nil {
+				cs.exitCode.Store(int32(cs.Cmd.ProcessState.ExitCode()))
+			}
+			cs.resources.release()
+		}
+		cs.recordExitStatus()
+		removeRecord(cs.Id)
+		close(cs.done)
+	})
+	return cs.waitErr
+}
+
+// markReaped marks the process of the command as about to be reaped. If Stop was called, the child processes remaining
+// in its group are killed first. On Linux, the command has exited but not been reaped yet at this point, so that the
+// group cannot have been reused. On other platforms, it has been reaped already and the group is left alone.
+func (cs *CmdState) markReaped() {
+	cs.reapMu.Lock()
+	defer cs.reapMu.Unlock()
+	if !cs.reaped && cs.stopRequested.Load() && cs.Cmd.ProcessState == nil {
+		killProcessGroup(cs.Cmd, cs.Cmd.Process)
+	}
+	cs.reaped = true
+}
+
+// Stop stops the command. It sends the stop signal (see Options.StopSignal) to the command's process group and
+// escalates to SIGKILL if the command has not exited after gracePeriod. On Linux, child processes remaining in the
+// group are killed once the command exited, unless it exited before Stop was called. If ctx is done first, the command
+// is killed right away and Stop returns ctx.Err() without waiting for it to exit.
+//
+// graceful reports whether the command exited before it had to be killed, which includes the command having exited
+// before Stop was called. For commands rehydrated from a previous run of the extension, ErrProcessGone is returned
+// instead if the process and, on Linux, the processes in its group were gone already.
+func (cs *CmdState) Stop(ctx context.Context, gracePeriod time.Duration) (graceful bool, err error) {
+	if !cs.started() {
+		return false, errors.New("command not started")
+	}
+	go func() { _ = cs.Wait() }()
+	if cs.rehydrated && !cs.alive() {
+		return false, ErrProcessGone
+	}
+
+	select {
+	case <-cs.done:
+		return true, nil
+	default:
+	}
+
+	stopSignal := cs.stopSignal
+	if stopSignal == nil {
+		stopSignal = defaultStopSignal
+	}
+	cs.stopRequested.Store(true)
+	if err := cs.signal(stopSignal); err != nil {
+		log.Debug().Err(err).Str("id", cs.Id).Msgf("failed to send %s, killing the command", stopSignal)
+		gracePeriod = 0
+	}
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-cs.done:
+		return true, nil
+	case <-ctx.Done():
+		cs.kill()
+		return false, ctx.Err()
+	case <-timer.C:
+	}
+
+	log.Debug().Str("id", cs.Id).Msgf("command did not stop within %s, killing it", gracePeriod)
+	cs.kill()
+	select {
+	case <-cs.done:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
 }
 
 // ExitCode returns the command's exit code, or -1 while it is still running or if it
 // was terminated by a signal, matching os.ProcessState.ExitCode(). It is safe to call
-// concurrently with the Wait goroutine.
+// concurrently with the Wait goroutine. See ExitStatus to tell these cases apart.
 func (cs *CmdState) ExitCode() int {
 	return int(cs.exitCode.Load())
 }
 
+// Write records p as output of the command's stdout.
 func (cs *CmdState) Write(p []byte) (n int, err error) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	return cs.out.Write(p)
+	return cs.write(Stdout, p)
 }
 
-func (cs *CmdState) GetLines(includePartialLines bool) []string {
+func (cs *CmdState) write(stream Stream, p []byte) (n int, err error) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
+	return cs.out.write(stream, p)
+}
 
-	var result []string
-	line, err := cs.out.ReadString('\n')
-	for ; err == nil; line, err = cs.out.ReadString('\n') {
-		result = append(result, line)
-	}
+// Stderr returns the writer that records output of the command's stderr.
+func (cs *CmdState) Stderr() io.Writer {
+	return stderrWriter{cs}
+}
 
-	if len(cs.lastPartialLineRead) > 0 && len(result) > 0 {
-		result[0] = fmt.Sprintf("%s%s", cs.lastPartialLineRead, result[0])
-		cs.lastPartialLineRead = ""
-	}
+type stderrWriter struct {
+	cs *CmdState
+}
 
-	if len(line) > 0 {
-		cs.lastPartialLineRead = fmt.Sprintf("%s%s", cs.lastPartialLineRead, line)
-	}
+func (w stderrWriter) Write(p []byte) (n int, err error) {
+	return w.cs.write(Stderr, p)
+}
 
-	if includePartialLines && len(cs.lastPartialLineRead) > 0 {
-		result = append(result, cs.lastPartialLineRead)
+// GetLines returns the complete lines written to stdout and stderr since the last call to GetLines or GetMessages. With
+// includePartialLines, the trailing lines that are not terminated yet are returned, too. They are returned again
+// (completed) by later calls.
+//
+// The output is retained up to the limits configured through Options. If it is not read in time, the oldest
+// lines are dropped, see DroppedOutput. Use Subscribe to read the output without consuming it.
+func (cs *CmdState) GetLines(includePartialLines bool) []string {
+	cs.touch()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	lines, _ := cs.out.read(includePartialLines)
+	result := make([]string, 0, len(lines))
+	for _, l := range lines {
+		result = append(result, l.Text)
 	}
-
 	return result
 }
 
+// DroppedOutput returns the number of lines and bytes that were dropped before being read, because the output
+// exceeded the configured limits. A line of which only the beginning was dropped is counted as well.
+func (cs *CmdState) DroppedOutput() (lines int, bytes int64) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.out.droppedLines, cs.out.droppedBytes
+}
+
 // Message is API compatible with ActionKit and DiscoveryKit Message
 type Message struct {
 	Level   *string `json:"level,omitempty"`
 	Message string  `json:"message"`
 }
 
+// GetMessages returns the lines like GetLines as messages. The level of a message is determined by the classifiers
+// configured through Options, lines not classified are "info" if written to stdout and Options.StderrLevel ("warn"
+// by default) if written to stderr. If lines were dropped since the last call, a warning stating their number precedes
+// them.
 func (cs *CmdState) GetMessages(includePartialMessages bool) []Message {
-	lines := cs.GetLines(includePartialMessages)
-	messages := make([]Message, 0, len(lines))
-	for _, line := range lines {
+	cs.touch()
+	cs.mu.Lock()
+	lines, dropped := cs.out.read(includePartialMessages)
+	cs.mu.Unlock()
+
+	messages := make([]Message, 0, len(lines)+1)
+	if dropped > 0 {
+		messages = append(messages, Message{
+			Level:   new(LevelWarn),
+			Message: fmt.Sprintf("%d lines truncated", dropped),
+		})
+	}
+	for _, l := range lines {
 		messages = append(messages, Message{
-			Level:   new("info"),
-			Message: line,
+			Level:   new(cs.classify(l)),
+			Message: l.Text,
 		})
 	}
 	return messages
@@ -8,7 +8,6 @@
 package extcmd
 
 import (
-	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os/exec"
@@ -20,7 +19,7 @@ import (
 func TestCmdStateReadsFullLines(t *testing.T) {
 	is := new(CmdState)
 	is.mu = new(sync.Mutex)
-	is.out = new(bytes.Buffer)
+	is.out = newOutputBuffer(Options{})
 
 	assert.Equal(t, 0, len(is.GetLines(false)))
 
@@ -80,3 +79,110 @@ func TestCmdStateExitCodeIsConcurrencySafe(t *testing.T) {
 	wg.Wait()
 	assert.Equal(t, 0, cs.ExitCode())
 }
+
+func TestCmdStateDropsOldestLines(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             Options
+		writes           []string
+		wantLines        []string
+		wantMessages     []string
+		wantDroppedLines int
+		wantDroppedBytes int64
+		includePartial   bool
+	}{
+		{
+			name:         "unlimited",
+			opts:         Options{MaxOutputBytes: -1},
+			writes:       []string{"a\nb\n", "c\n"},
+			wantLines:    []string{"a\n", "b\n", "c\n"},
+			wantMessages: []string{"a\n", "b\n", "c\n"},
+		},
+		{
+			name:             "line limit",
+			opts:             Options{MaxOutputLines: 2},
+			writes:           []string{"a\nb\n", "c\nd\n"},
+			wantLines:        []string{"c\n", "d\n"},
+			wantMessages:     []string{"2 lines truncated", "c\n", "d\n"},
+			wantDroppedLines: 2,
+			wantDroppedBytes: 4,
+		},
+		{
+			name:             "byte limit",
+			opts:             Options{MaxOutputBytes: 8},
+			writes:           []string{"first\n", "second\n", "third\n"},
+			wantLines:        []string{"third\n"},
+			wantMessages:     []string{"2 lines truncated", "third\n"},
+			wantDroppedLines: 2,
+			wantDroppedBytes: 13,
+		},
+		{
+			name:             "long partial line",
+			opts:             Options{MaxOutputBytes: 4},
+			writes:           []string{"a\n", "0123", "456789"},
+			includePartial:   true,
+			wantLines:        []string{"6789"},
+			wantMessages:     []string{"2 lines truncated", "6789"},
+			wantDroppedLines: 2,
+			wantDroppedBytes: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, read := range []string{"lines", "messages"} {
+				cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(tt.opts)}
+				for _, w := range tt.writes {
+					_, err := cs.Write([]byte(w))
+					require.NoError(t, err)
+				}
+
+				if read == "lines" {
+					assert.Equal(t, tt.wantLines, cs.GetLines(tt.includePartial))
+				} else {
+					var messages []string
+					for _, m := range cs.GetMessages(tt.includePartial) {
+						messages = append(messages, m.Message)
+					}
+					assert.Equal(t, tt.wantMessages, messages)
+					assert.Empty(t, cs.GetMessages(false), "truncation is only reported once")
+				}
+
+				lines, bytes := cs.DroppedOutput()
+				assert.Equal(t, tt.wantDroppedLines, lines)
+				assert.Equal(t, tt.wantDroppedBytes, bytes)
+			}
+		})
+	}
+}
+
+func TestCmdStateDoesNotCountReadLinesAsDropped(t *testing.T) {
+	cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{MaxOutputLines: 1})}
+
+	_, _ = cs.Write([]byte("a\n"))
+	assert.Equal(t, []string{"a\n"}, cs.GetLines(false))
+	_, _ = cs.Write([]byte("b\nc\n"))
+
+	messages := cs.GetMessages(false)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "warn", *messages[0].Level)
+	assert.Equal(t, "1 lines truncated", messages[0].Message)
+	assert.Equal(t, "c\n", messages[1].Message)
+}
+
+func TestCmdStateKeepsStreamsApart(t *testing.T) {
+	cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{})}
+
+	_, _ = cs.Write([]byte("out "))
+	_, _ = cs.Stderr().Write([]byte("err "))
+	_, _ = cs.Write([]byte("line\n"))
+	_, _ = cs.Stderr().Write([]byte("line\npartial"))
+
+	messages := cs.GetMessages(true)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "out line\n", messages[0].Message)
+	assert.Equal(t, LevelInfo, *messages[0].Level)
+	assert.Equal(t, "err line\n", messages[1].Message)
+	assert.Equal(t, LevelWarn, *messages[1].Level)
+	assert.Equal(t, "partial", messages[2].Message)
+	assert.Equal(t, LevelWarn, *messages[2].Level)
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a typed event parsed from the output of a command, see CmdState.GetEvents. It is one of ProgressEvent,
+// MetricEvent or MessageEvent.
+type Event interface {
+	isEvent()
+}
+
+// ProgressEvent reports the progress of a command.
+type ProgressEvent struct {
+	// Percent is the progress in percent, from 0 to 100. Values parsed outside this range are clamped.
+	Percent float64
+	Message string
+}
+
+// MetricEvent is a sample of a metric reported by a command.
+type MetricEvent struct {
+	Name      string
+	Value     float64
+	Labels    map[string]string
+	Timestamp time.Time
+}
+
+// MessageEvent is a log message of a command.
+type MessageEvent struct {
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+func (ProgressEvent) isEvent() {}
+func (MetricEvent) isEvent()   {}
+func (MessageEvent) isEvent()  {}
+
+// LineParser parses a line of output into events. It returns false if it cannot parse the line, the next parser is
+// asked then. The line includes its trailing newline, if any. A parser may return no events to drop the line.
+type LineParser func(stream Stream, line string) (events []Event, ok bool)
+
+// ProgressParser parses progress from lines matching pattern, which is matched against the line without its trailing
+// newline. The percentage is taken from the submatch named "percent", or the first submatch if there is none by that
+// name. Without a pattern, a number followed by a percent sign is matched, e.g., "42.5%". An error is returned if the
+// pattern has no submatch.
+func ProgressParser(pattern *regexp.Regexp, streams ...Stream) (LineParser, error) {
+	if pattern == nil {
+		pattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	}
+	if pattern.NumSubexp() == 0 {
+		return nil, fmt.Errorf("pattern %q has no submatch for the percentage", pattern)
+	}
+	group := pattern.SubexpIndex("percent")
+	if group < 0 {
+		group = 1
+	}
+	return func(stream Stream, line string) ([]Event, bool) {
+		if !streamMatches(streams, stream) {
+			return nil, false
+		}
+		match := pattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil {
+			return nil, false
+		}
+		percent, err := strconv.ParseFloat(match[group], 64)
+		if err != nil {
+			return nil, false
+		}
+		return []Event{ProgressEvent{Percent: min(max(percent, 0), 100), Message: strings.TrimSpace(line)}}, true
+	}, nil
+}
+
+// MetricParser parses metric samples from lines matching pattern, which is matched against the line without its
+// trailing newline. The value is taken from the submatch named "value", the name from the one named "name", falling
+// back to the given name. All other named submatches become labels, e.g., `(?P<interface>\w+): (?P<value>[\d.]+)
+// Mbits/sec`. An error is returned if there is no pattern or it has no submatch named "value".
+func MetricParser(pattern *regexp.Regexp, name string, streams ...Stream) (LineParser, error) {
+	if pattern == nil {
+		return nil, errors.New("pattern is required")
+	}
+	valueGroup := pattern.SubexpIndex("value")
+	if valueGroup < 0 {
+		return nil, fmt.Errorf("pattern %q has no submatch named \"value\"", pattern)
+	}
+	nameGroup := pattern.SubexpIndex("name")
+	return func(stream Stream, line string) ([]Event, bool) {
+		if !streamMatches(streams, stream) {
+			return nil, false
+		}
+		match := pattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil {
+			return nil, false
+		}
+		value, err := strconv.ParseFloat(match[valueGroup], 64)
+		if err != nil {
+			return nil, false
+		}
+		metric := MetricEvent{Name: name, Value: value}
+		for i, group := range pattern.SubexpNames() {
+			switch {
+			case i == 0 || group == "" || i == valueGroup:
+			case i == nameGroup:
+				metric.Name = match[i]
+			default:
+				if metric.Labels == nil {
+					metric.Labels = map[string]string{}
+				}
+				metric.Labels[group] = match[i]
+			}
+		}
+		return []Event{metric}, true
+	}, nil
+}
+
+// JsonLinesParser parses lines consisting of a JSON object into messages, as written by structured loggers like
+// zerolog, logrus or slog. The level is read and normalized like JsonLogClassifier does, the message is read from the
+// "message" or "msg" key. All other members become fields of the message. Without a level, the level is determined
+// like GetMessages does.
+func JsonLinesParser(streams ...Stream) LineParser {
+	return func(stream Stream, line string) ([]Event, bool) {
+		line = strings.TrimSpace(line)
+		if !streamMatches(streams, stream) || !strings.HasPrefix(line, "{") {
+			return nil, false
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, false
+		}
+
+		var message MessageEvent
+		if level, ok := entry["level"].(string); ok {
+			if normalized, ok := normalizeLevel(level); ok {
+				message.Level = normalized
+			}
+			delete(entry, "level")
+		}
+		for _, key := range []string{"message", "msg"} {
+			if text, ok := entry[key].(string); ok && message.Message == "" {
+				message.Message = text
+				delete(entry, key)
+			}
+		}
+		if len(entry) > 0 {
+			message.Fields = make(map[string]string, len(entry))
+			for key, value := range entry {
+				message.Fields[key] = formatField(value)
+			}
+		}
+		return []Event{message}, true
+	}
+}
+
+func formatField(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// GetEvents returns the lines like GetLines as events. Each line is parsed by the first parser configured through
+// Options.Parsers that applies to it. Lines not parsed become a MessageEvent with the level determined like
+// GetMessages does. Metrics without timestamp are stamped with the time the line was written. If lines were dropped
+// since the last call, a warning stating their number precedes the events. Messages without level are classified like
+// GetMessages does.
+func (cs *CmdState) GetEvents(includePartialLines bool) []Event {
+	cs.touch()
+	cs.mu.Lock()
+	lines, dropped := cs.out.read(includePartialLines)
+	cs.mu.Unlock()
+
+	events := make([]Event, 0, len(lines)+1)
+	if dropped > 0 {
+		events = append(events, MessageEvent{Level: LevelWarn, Message: fmt.Sprintf("%d lines truncated", dropped)})
+	}
+	for _, l := range lines {
+		events = append(events, cs.parse(l)...)
+	}
+	return events
+}
+
+func (cs *CmdState) parse(l Line) []Event {
+	for _, parser := range cs.parsers {
+		events, ok := parser(l.Stream, l.Text)
+		if !ok {
+			continue
+		}
+		for i, event := range events {
+			switch e := event.(type) {
+			case MetricEvent:
+				if e.Timestamp.IsZero() {
+					e.Timestamp = l.Time
+					events[i] = e
+				}
+			case MessageEvent:
+				if e.Level == "" {
+					e.Level = cs.classify(l)
+					events[i] = e
+				}
+			}
+		}
+		return events
+	}
+	return []Event{MessageEvent{Level: cs.classify(l), Message: l.Text}}
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdStateGetEvents(t *testing.T) {
+	written := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name       string
+		parsers    []LineParser
+		stream     Stream
+		line       string
+		wantEvents []Event
+	}{
+		{
+			name:       "without parsers",
+			stream:     Stderr,
+			line:       "hello\n",
+			wantEvents: []Event{MessageEvent{Level: LevelWarn, Message: "hello\n"}},
+		},
+		{
+			name:       "progress",
+			parsers:    []LineParser{mustParser(ProgressParser(nil))},
+			line:       "stress-ng: info: 42.5% complete\n",
+			wantEvents: []Event{ProgressEvent{Percent: 42.5, Message: "stress-ng: info: 42.5% complete"}},
+		},
+		{
+			name:       "progress with named submatch",
+			parsers:    []LineParser{mustParser(ProgressParser(regexp.MustCompile(`step (\d+) of \d+ \((?P<percent>\d+)%\)`)))},
+			line:       "step 3 of 4 (75%)\n",
+			wantEvents: []Event{ProgressEvent{Percent: 75, Message: "step 3 of 4 (75%)"}},
+		},
+		{
+			name:       "progress above 100 percent",
+			parsers:    []LineParser{mustParser(ProgressParser(regexp.MustCompile(`(-?\d+)%`)))},
+			line:       "150%\n",
+			wantEvents: []Event{ProgressEvent{Percent: 100, Message: "150%"}},
+		},
+		{
+			name:       "progress below 0 percent",
+			parsers:    []LineParser{mustParser(ProgressParser(regexp.MustCompile(`(-?\d+)%`)))},
+			line:       "-5%\n",
+			wantEvents: []Event{ProgressEvent{Percent: 0, Message: "-5%"}},
+		},
+		{
+			name:    "progress limited to other stream",
+			parsers: []LineParser{mustParser(ProgressParser(nil, Stderr))},
+			stream:  Stdout,
+			line:    "50%\n",
+			wantEvents: []Event{
+				MessageEvent{Level: LevelInfo, Message: "50%\n"},
+			},
+		},
+		{
+			name:    "metric",
+			parsers: []LineParser{mustParser(MetricParser(regexp.MustCompile(`(?P<interface>\w+): (?P<value>[\d.]+) Mbits/sec`), "bandwidth"))},
+			line:    "eth0: 941.5 Mbits/sec\n",
+			wantEvents: []Event{MetricEvent{
+				Name:      "bandwidth",
+				Value:     941.5,
+				Labels:    map[string]string{"interface": "eth0"},
+				Timestamp: written,
+			}},
+		},
+		{
+			name:       "metric with name submatch",
+			parsers:    []LineParser{mustParser(MetricParser(regexp.MustCompile(`^(?P<name>\w+)=(?P<value>\d+)$`), "unused"))},
+			line:       "bogo_ops=12\n",
+			wantEvents: []Event{MetricEvent{Name: "bogo_ops", Value: 12, Timestamp: written}},
+		},
+		{
+			name:    "json lines",
+			parsers: []LineParser{JsonLinesParser()},
+			line:    `{"level":"warning","msg":"slow","latency":1.5,"ok":false,"tags":["a"],"host":"h"}` + "\n",
+			wantEvents: []Event{MessageEvent{
+				Level:   LevelWarn,
+				Message: "slow",
+				Fields:  map[string]string{"latency": "1.5", "ok": "false", "tags": `["a"]`, "host": "h"},
+			}},
+		},
+		{
+			name:       "json lines without level",
+			parsers:    []LineParser{JsonLinesParser()},
+			stream:     Stderr,
+			line:       `{"message":"started"}`,
+			wantEvents: []Event{MessageEvent{Level: LevelWarn, Message: "started"}},
+		},
+		{
+			name: "first parser wins",
+			parsers: []LineParser{
+				JsonLinesParser(),
+				mustParser(ProgressParser(nil)),
+			},
+			line:       `{"level":"info","message":"10%"}`,
+			wantEvents: []Event{MessageEvent{Level: LevelInfo, Message: "10%"}},
+		},
+		{
+			name: "parser dropping the line",
+			parsers: []LineParser{func(Stream, string) ([]Event, bool) {
+				return nil, true
+			}},
+			line:       "noise\n",
+			wantEvents: []Event{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{}), parsers: tt.parsers}
+			_, _ = cs.write(tt.stream, []byte(tt.line))
+			for i := range cs.out.lines {
+				cs.out.lines[i].Time = written
+			}
+
+			assert.Equal(t, tt.wantEvents, cs.GetEvents(true))
+		})
+	}
+}
+
+func TestParsersRejectInvalidPatterns(t *testing.T) {
+	_, err := ProgressParser(regexp.MustCompile(`\d+%`))
+	assert.EqualError(t, err, "pattern \"\\\\d+%\" has no submatch for the percentage")
+
+	_, err = MetricParser(nil, "bandwidth")
+	assert.EqualError(t, err, "pattern is required")
+	_, err = MetricParser(regexp.MustCompile(`(?P<interface>\w+): ([\d.]+) Mbits/sec`), "bandwidth")
+	assert.ErrorContains(t, err, `has no submatch named "value"`)
+}
+
+func mustParser(parser LineParser, err error) LineParser {
+	if err != nil {
+		panic(err)
+	}
+	return parser
+}
+
+func TestCmdStateGetEventsReportsTruncation(t *testing.T) {
+	cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{MaxOutputLines: 1})}
+	_, _ = cs.Write([]byte("a\nb\n"))
+
+	events := cs.GetEvents(false)
+	require.Len(t, events, 2)
+	assert.Equal(t, MessageEvent{Level: LevelWarn, Message: "1 lines truncated"}, events[0])
+	assert.Equal(t, MessageEvent{Level: LevelInfo, Message: "b\n"}, events[1])
+	assert.Empty(t, cs.GetEvents(true))
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"os"
+	"time"
+)
+
+// ExitStatus describes how a command exited, see CmdState.ExitStatus.
+type ExitStatus struct {
+	// Exited is set once the command has exited. The other fields, except Duration, are only set then.
+	Exited bool
+	// ExitCode is the exit code of the command, or -1 if it was terminated by a signal.
+	ExitCode int
+	// Signal is the signal that terminated the command, if any. Always nil on Windows.
+	Signal os.Signal
+	// KilledByUs is set if the command exited after it was asked to stop through CmdState.Stop, StopAll or because it
+	// was reaped.
+	KilledByUs bool
+	// OOMKilled is set if the OOM killer killed a process of the command. It is only detected for commands placed in a
+	// cgroup, see Options.Cgroup. Otherwise, termination by SIGKILL without KilledByUs hints at it.
+	OOMKilled bool
+	// Duration is the time the command has been running for, or ran for if it exited. It is only known for commands
+	// started through CmdState.Start.
+	Duration time.Duration
+	// MaxRSS is the maximum resident set size of the command in bytes. Zero on Windows.
+	MaxRSS int64
+	// UserTime and SystemTime are the CPU time the command and its waited-for children spent in user and system mode.
+	UserTime   time.Duration
+	SystemTime time.Duration
+}
+
+// Done returns a channel that is closed once the command exited and Wait returned. Commands not started through Start
+// must be waited for through Wait for the channel to be closed.
+func (cs *CmdState) Done() <-chan struct{} {
+	return cs.done
+}
+
+// ExitStatus returns the exit status of the command. It is safe to call concurrently with the Wait goroutine. For
+// commands rehydrated from a previous run of the extension, only Exited and Duration are known.
+func (cs *CmdState) ExitStatus() ExitStatus {
+	select {
+	case <-cs.done:
+		return cs.exitStatus
+	default:
+	}
+	status := ExitStatus{ExitCode: -1}
+	if (cs.process.Load() != nil || cs.runnerProcess.Load() != nil) && !cs.startTime.IsZero() {
+		status.Duration = time.Since(cs.startTime)
+	}
+	return status
+}
+
+// recordExitStatus records the exit status once the command has been waited for.
+func (cs *CmdState) recordExitStatus() {
+	status := ExitStatus{
+		Exited:     true,
+		ExitCode:   -1,
+		KilledByUs: cs.stopRequested.Load(),
+		OOMKilled:  cs.resources.oomKilled,
+	}
+	if !cs.startTime.IsZero() {
+		status.Duration = time.Since(cs.startTime)
+	}
+	if cs.runnerProcess.Load() != nil {
+		status.ExitCode = cs.runnerStatus.ExitCode
+		status.Signal = cs.runnerStatus.Signal
+		status.MaxRSS = cs.runnerStatus.MaxRSS
+		status.UserTime = cs.runnerStatus.UserTime
+		status.SystemTime = cs.runnerStatus.SystemTime
+	} else if state := cs.Cmd.ProcessState; state != nil && !cs.rehydrated {
+		status.ExitCode = state.ExitCode()
+		status.Signal, status.MaxRSS = processUsage(state)
+		status.UserTime = state.UserTime()
+		status.SystemTime = state.SystemTime()
+	}
+	cs.exitStatus = status
+}
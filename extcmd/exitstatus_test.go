@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !windows
+
+package extcmd
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdStateExitStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		script         string
+		stop           func(t *testing.T, cs *CmdState)
+		wantExitCode   int
+		wantSignal     syscall.Signal
+		wantKilledByUs bool
+	}{
+		{
+			name:         "exit code",
+			script:       "exit 3",
+			wantExitCode: 3,
+		},
+		{
+			name:   "stopped",
+			script: "sleep 60",
+			stop: func(t *testing.T, cs *CmdState) {
+				_, err := cs.Stop(t.Context(), time.Second)
+				require.NoError(t, err)
+			},
+			wantExitCode:   -1,
+			wantSignal:     syscall.SIGTERM,
+			wantKilledByUs: true,
+		},
+		{
+			name:   "killed by someone else",
+			script: "sleep 60",
+			stop: func(t *testing.T, cs *CmdState) {
+				// Kill the whole group, a forked sleep would keep the output open otherwise.
+				require.NoError(t, syscall.Kill(-cs.Cmd.Process.Pid, syscall.SIGKILL))
+			},
+			wantExitCode: -1,
+			wantSignal:   syscall.SIGKILL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := NewCmdState(exec.Command("sh", "-c", tt.script))
+			defer RemoveCmdState(cs.Id)
+			require.NoError(t, cs.Start())
+			if tt.stop != nil {
+				running := cs.ExitStatus()
+				assert.False(t, running.Exited)
+				assert.Equal(t, -1, running.ExitCode)
+				tt.stop(t, cs)
+			}
+
+			select {
+			case <-cs.Done():
+			case <-time.After(10 * time.Second):
+				require.Fail(t, "command did not exit")
+			}
+			status := cs.ExitStatus()
+			assert.True(t, status.Exited)
+			assert.Equal(t, tt.wantExitCode, status.ExitCode)
+			if tt.wantSignal != 0 {
+				assert.Equal(t, tt.wantSignal, status.Signal)
+			} else {
+				assert.Nil(t, status.Signal)
+			}
+			assert.Equal(t, tt.wantKilledByUs, status.KilledByUs)
+			assert.False(t, status.OOMKilled)
+			assert.Positive(t, status.Duration)
+			assert.Positive(t, status.MaxRSS)
+		})
+	}
+}
@@ -10,31 +10,99 @@
 package extcmd
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"os"
 	"os/exec"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 var states = sync.Map{}
 
+// DefaultStopGracePeriod is the time a command stopped by this package is given to stop before it is killed, unless
+// configured otherwise through Options.StopGracePeriod.
+const DefaultStopGracePeriod = 10 * time.Second
+
+// Options configures a CmdState, see NewCmdStateWithOptions.
+type Options struct {
+	// MaxOutputBytes limits the output that is retained until it is read. The oldest lines are dropped first.
+	// Defaults to DefaultMaxOutputBytes, a negative value disables the limit.
+	MaxOutputBytes int
+	// MaxOutputLines limits the number of lines that are retained until they are read. Zero disables the limit.
+	MaxOutputLines int
+	// Classifiers determine the level of the messages returned by CmdState.GetMessages. The first classifier that
+	// applies to a line wins, see RegexClassifier and JsonLogClassifier.
+	Classifiers []Classifier
+	// StderrLevel is the level of lines written to stderr that no classifier applies to. Defaults to LevelWarn.
+	StderrLevel string
+	// Parsers turn the output into typed events returned by CmdState.GetEvents. The first parser that applies to a line
+	// wins, see ProgressParser, MetricParser and JsonLinesParser.
+	Parsers []LineParser
+	// StopSignal is sent by CmdState.Stop to ask the command to stop. Defaults to SIGTERM, on Windows, where signals
+	// other than os.Kill are not supported, commands are killed right away.
+	StopSignal os.Signal
+	// IdleTimeout enables reaping of the CmdState: if it is not polled through GetCmdState, CmdState.GetLines or
+	// CmdState.GetMessages within the timeout, the command is stopped and the state is removed, e.g., because the agent
+	// never called stop. Hooks registered through OnReap are notified. Zero disables reaping.
+	IdleTimeout time.Duration
+	// StopGracePeriod is the grace period passed to CmdState.Stop when the command is stopped by this package, i.e.,
+	// when it is reaped or stopped through StopAll. Defaults to DefaultStopGracePeriod.
+	StopGracePeriod time.Duration
+	// Rlimits are applied to the command when it is started through CmdState.Start. Linux only. Like Nice, they are
+	// applied before the command is executed, by starting the extension binary (/proc/self/exe) first, which applies
+	// them to itself and then executes the command.
+	Rlimits []Rlimit
+	// Nice is the nice value of the command when it is started through CmdState.Start, see setpriority(2). Zero keeps
+	// the nice value of the extension. Linux only.
+	Nice int
+	// Cgroup places the command into a dedicated cgroup v2 with CPU and memory caps when it is started through
+	// CmdState.Start. Once the command exited, the processes remaining in the cgroup are killed and the cgroup is
+	// removed. Linux only, requires kernel 5.7 or later.
+	Cgroup *CgroupOptions
+}
+
 // NewCmdState create a new CmdState and registers it as a global state. The expected call pattern
 // is that NewCmdState is called immediately after the exec.Cmd is created, but before the Cmd
-// is started.
+// is started, preferably through CmdState.Start.
 func NewCmdState(cmd *exec.Cmd) *CmdState {
+	return NewCmdStateWithOptions(cmd, Options{})
+}
+
+// NewCmdStateWithOptions behaves like NewCmdState, but configures the CmdState through opts.
+func NewCmdStateWithOptions(cmd *exec.Cmd, opts Options) *CmdState {
+	state := newCmdState(uuid.NewString(), cmd, opts)
+	states.Store(state.Id, state)
+	state.startReaping()
+	return state
+}
+
+func newCmdState(id string, cmd *exec.Cmd, opts Options) *CmdState {
 	state := new(CmdState)
-	state.Id = uuid.NewString()
+	state.Id = id
 	state.Cmd = cmd
 	state.exitCode.Store(-1)
-	state.out = new(bytes.Buffer)
+	state.out = newOutputBuffer(opts)
 	state.mu = new(sync.Mutex)
+	state.classifiers = opts.Classifiers
+	state.stderrLevel = opts.StderrLevel
+	state.parsers = opts.Parsers
+	state.stopSignal = opts.StopSignal
+	state.resources = newResources(opts)
+	state.done = make(chan struct{})
+	state.idleTimeout = opts.IdleTimeout
+	state.stopGracePeriod = opts.StopGracePeriod
+	if state.stopGracePeriod <= 0 {
+		state.stopGracePeriod = DefaultStopGracePeriod
+	}
 
 	cmd.Stdout = state
-	cmd.Stderr = state
-
-	states.Store(state.Id, state)
-
+	cmd.Stderr = state.Stderr()
 	return state
 }
 
@@ -45,11 +113,57 @@ func GetCmdState(id string) (*CmdState, error) {
 	if !ok {
 		return nil, fmt.Errorf("failed to find a command state with ID '%s'", id)
 	}
+	state.(*CmdState).touch()
 	return state.(*CmdState), nil
 }
 
 // RemoveCmdState removes the state with the given ID. A no-op in case there is no state with this ID.
-// It is the caller's responsibility to ensure that the exec.Cmd itself is stopped.
+// It is the caller's responsibility to ensure that the exec.Cmd itself is stopped, e.g., through CmdState.Stop.
 func RemoveCmdState(id string) {
-	states.Delete(id)
+	if state, ok := states.LoadAndDelete(id); ok {
+		state.(*CmdState).stopReaping()
+		removeRecord(id)
+	}
+}
+
+// ListCmdStates returns all registered states, ordered by their ID.
+func ListCmdStates() []*CmdState {
+	var result []*CmdState
+	states.Range(func(_, value any) bool {
+		result = append(result, value.(*CmdState))
+		return true
+	})
+	slices.SortFunc(result, func(a, b *CmdState) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+	return result
+}
+
+// StopAll stops the commands of all registered states in parallel, see CmdState.Stop. Each command is given the grace
+// period configured through Options.StopGracePeriod, commands that are still running when ctx is done are killed. The
+// states remain registered. The errors of the individual commands are joined.
+func StopAll(ctx context.Context) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for _, state := range ListCmdStates() {
+		if !state.started() {
+			continue
+		}
+		wg.Go(func() {
+			graceful, err := state.Stop(ctx, state.stopGracePeriod)
+			if errors.Is(err, ErrProcessGone) {
+				return
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("failed to stop command %s (%s): %w", state.Id, state.Cmd.Path, err))
+				mu.Unlock()
+				return
+			}
+			log.Debug().Str("id", state.Id).Bool("graceful", graceful).Msgf("stopped command %s", state.Cmd.Path)
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FakeRunner is a Runner for tests that plays scripted commands instead of starting processes, so that the command
+// flow of an extension can be tested without the tools it runs being installed:
+//
+//	fake := extcmd.NewFakeRunner()
+//	fake.On("stress-ng").Stdout("stress-ng: info: dispatching hogs").Sleep(time.Second).Exit(0)
+//	defer extcmd.SetRunner(fake)()
+//
+// Commands are matched by the base name of their first argument. Starting a command that has not been scripted fails
+// like starting one that is not installed.
+//
+// The runner only plays commands started through CmdState.Start. Commands the extension starts directly through
+// cmd.Start() followed by `go cs.Wait()` bypass it and run the real tools, see SetRunner.
+type FakeRunner struct {
+	mu          sync.Mutex
+	commands    map[string]*FakeCommand
+	invocations []Invocation
+}
+
+// Invocation records a command started through a FakeRunner.
+type Invocation struct {
+	Args []string
+	// Env is the environment set on the command, nil if it inherits the environment of the extension.
+	Env []string
+	Dir string
+}
+
+// NewFakeRunner creates a FakeRunner without scripted commands.
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{commands: map[string]*FakeCommand{}}
+}
+
+// On returns the script of the command with the given name, which exits with 0 right away unless scripted otherwise.
+// The script must be complete before the command is started.
+func (f *FakeRunner) On(name string) *FakeCommand {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.commands[name]
+	if !ok {
+		c = &FakeCommand{}
+		f.commands[name] = c
+	}
+	return c
+}
+
+// Invocations returns the commands started so far, including those that failed to start.
+func (f *FakeRunner) Invocations() []Invocation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.invocations)
+}
+
+func (f *FakeRunner) Start(cmd *exec.Cmd) (Process, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invocations = append(f.invocations, Invocation{
+		Args: slices.Clone(cmd.Args),
+		Env:  slices.Clone(cmd.Env),
+		Dir:  cmd.Dir,
+	})
+
+	name := cmd.Path
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+	c, ok := f.commands[filepath.Base(name)]
+	if !ok {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	if c.startErr != nil {
+		return nil, c.startErr
+	}
+
+	p := &fakeProcess{
+		script:  *c,
+		stdout:  writerOrDiscard(cmd.Stdout),
+		stderr:  writerOrDiscard(cmd.Stderr),
+		signals: make(chan os.Signal),
+		done:    make(chan struct{}),
+	}
+	p.script.steps = slices.Clone(c.steps)
+	go p.run()
+	return p, nil
+}
+
+func writerOrDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
+
+// FakeCommand is the script of a command played by a FakeRunner. The steps are played in the order they were added.
+type FakeCommand struct {
+	steps    []fakeStep
+	exitCode int
+	// untilSignaled keeps the command running after the steps until it is terminated by a signal.
+	untilSignaled bool
+	ignored       []os.Signal
+	startErr      error
+}
+
+type fakeStep struct {
+	stream Stream
+	lines  []string
+	delay  time.Duration
+}
+
+// Stdout writes lines to stdout, each terminated by a newline.
+func (c *FakeCommand) Stdout(lines ...string) *FakeCommand {
+	c.steps = append(c.steps, fakeStep{stream: Stdout, lines: lines})
+	return c
+}
+
+// Stderr writes lines to stderr, each terminated by a newline.
+func (c *FakeCommand) Stderr(lines ...string) *FakeCommand {
+	c.steps = append(c.steps, fakeStep{stream: Stderr, lines: lines})
+	return c
+}
+
+// Sleep waits for d. A signal received meanwhile terminates the command, unless it is ignored.
+func (c *FakeCommand) Sleep(d time.Duration) *FakeCommand {
+	c.steps = append(c.steps, fakeStep{delay: d})
+	return c
+}
+
+// Exit sets the exit code of the command once all steps were played.
+func (c *FakeCommand) Exit(code int) *FakeCommand {
+	c.exitCode = code
+	c.untilSignaled = false
+	return c
+}
+
+// RunUntilSignaled keeps the command running once all steps were played until it is terminated by a signal, like a
+// stress tool running until it is stopped.
+func (c *FakeCommand) RunUntilSignaled() *FakeCommand {
+	c.untilSignaled = true
+	return c
+}
+
+// IgnoreSignals makes the command ignore sigs, e.g., to test the escalation of CmdState.Stop. os.Kill cannot be
+// ignored.
+func (c *FakeCommand) IgnoreSignals(sigs ...os.Signal) *FakeCommand {
+	c.ignored = append(c.ignored, sigs...)
+	return c
+}
+
+// FailStart makes starting the command fail with err.
+func (c *FakeCommand) FailStart(err error) *FakeCommand {
+	c.startErr = err
+	return c
+}
+
+// fakeProcess plays a FakeCommand.
+type fakeProcess struct {
+	script  FakeCommand
+	stdout  io.Writer
+	stderr  io.Writer
+	signals chan os.Signal
+	done    chan struct{}
+	status  ExitStatus
+	err     error
+}
+
+func (p *fakeProcess) run() {
+	defer close(p.done)
+	for _, step := range p.script.steps {
+		if step.delay > 0 {
+			if sig := p.sleep(step.delay); sig != nil {
+				p.terminate(sig)
+				return
+			}
+			continue
+		}
+		w := p.stdout
+		if step.stream == Stderr {
+			w = p.stderr
+		}
+		for _, line := range step.lines {
+			_, _ = io.WriteString(w, line+"\n")
+		}
+	}
+	if p.script.untilSignaled {
+		for sig := range p.signals {
+			if !p.ignores(sig) {
+				p.terminate(sig)
+				return
+			}
+		}
+	}
+	p.status = ExitStatus{ExitCode: p.script.exitCode}
+	if p.script.exitCode != 0 {
+		p.err = fmt.Errorf("exit status %d", p.script.exitCode)
+	}
+}
+
+// sleep waits for d and returns the signal that terminated the command meanwhile, if any.
+func (p *fakeProcess) sleep(d time.Duration) os.Signal {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return nil
+		case sig := <-p.signals:
+			if !p.ignores(sig) {
+				return sig
+			}
+		}
+	}
+}
+
+func (p *fakeProcess) ignores(sig os.Signal) bool {
+	return sig != os.Kill && slices.Contains(p.script.ignored, sig)
+}
+
+func (p *fakeProcess) terminate(sig os.Signal) {
+	p.status = ExitStatus{ExitCode: -1, Signal: sig}
+	p.err = fmt.Errorf("signal: %s", sig)
+}
+
+// Signal delivers sig to the command. It is only received while the command sleeps or runs until signaled.
+func (p *fakeProcess) Signal(sig os.Signal) error {
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	case p.signals <- sig:
+		return nil
+	}
+}
+
+func (p *fakeProcess) Wait() (ExitStatus, error) {
+	<-p.done
+	return p.status, p.err
+}
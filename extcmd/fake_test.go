@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeRunner(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       func(c *FakeCommand)
+		stop         bool
+		wantLines    []string
+		wantExitCode int
+		wantSignal   os.Signal
+		wantGraceful bool
+		wantErr      bool
+	}{
+		{
+			name: "output and exit code",
+			script: func(c *FakeCommand) {
+				c.Stdout("starting").Sleep(10 * time.Millisecond).Stderr("failed").Exit(2)
+			},
+			wantLines:    []string{"starting\n", "failed\n"},
+			wantExitCode: 2,
+			wantErr:      true,
+		},
+		{
+			name: "stopped",
+			script: func(c *FakeCommand) {
+				c.Stdout("running").RunUntilSignaled()
+			},
+			stop:         true,
+			wantLines:    []string{"running\n"},
+			wantExitCode: -1,
+			wantSignal:   defaultStopSignal,
+			wantGraceful: true,
+			wantErr:      true,
+		},
+		{
+			name: "stopped while sleeping",
+			script: func(c *FakeCommand) {
+				c.Sleep(time.Minute).Stdout("never")
+			},
+			stop:         true,
+			wantLines:    []string{},
+			wantExitCode: -1,
+			wantSignal:   defaultStopSignal,
+			wantGraceful: true,
+			wantErr:      true,
+		},
+		{
+			name: "stop signal ignored",
+			script: func(c *FakeCommand) {
+				c.RunUntilSignaled().IgnoreSignals(defaultStopSignal)
+			},
+			stop:         true,
+			wantLines:    []string{},
+			wantExitCode: -1,
+			wantSignal:   os.Kill,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeRunner()
+			tt.script(fake.On("stress-ng"))
+			defer SetRunner(fake)()
+
+			cs := NewCmdState(exec.Command("stress-ng", "--cpu", "1"))
+			defer RemoveCmdState(cs.Id)
+			require.NoError(t, cs.Start())
+
+			if tt.stop {
+				graceful, err := cs.Stop(t.Context(), 50*time.Millisecond)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantGraceful, graceful)
+			}
+			err := cs.Wait()
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			status := cs.ExitStatus()
+			assert.True(t, status.Exited)
+			assert.Equal(t, tt.wantExitCode, status.ExitCode)
+			assert.Equal(t, tt.wantExitCode, cs.ExitCode())
+			assert.Equal(t, tt.wantSignal, status.Signal)
+			assert.Equal(t, tt.stop, status.KilledByUs)
+			assert.Equal(t, tt.wantLines, cs.GetLines(false))
+		})
+	}
+}
+
+func TestFakeRunnerStreams(t *testing.T) {
+	fake := NewFakeRunner()
+	fake.On("tc").Stdout("out").Stderr("err")
+	defer SetRunner(fake)()
+
+	cs := NewCmdState(exec.Command("tc", "qdisc", "add"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	<-cs.Done()
+
+	messages := cs.GetMessages(false)
+	require.Len(t, messages, 2)
+	assert.Equal(t, LevelInfo, *messages[0].Level)
+	assert.Equal(t, LevelWarn, *messages[1].Level)
+}
+
+func TestFakeRunnerInvocations(t *testing.T) {
+	fake := NewFakeRunner()
+	fake.On("tc")
+	defer SetRunner(fake)()
+
+	cmd := exec.Command("/usr/sbin/tc", "qdisc", "add")
+	cmd.Env = []string{"LANG=C"}
+	cmd.Dir = "/tmp"
+	cs := NewCmdState(cmd)
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+
+	missing := NewCmdState(exec.Command("stress-ng"))
+	defer RemoveCmdState(missing.Id)
+	err := missing.Start()
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+
+	assert.Equal(t, []Invocation{
+		{Args: []string{"/usr/sbin/tc", "qdisc", "add"}, Env: []string{"LANG=C"}, Dir: "/tmp"},
+		{Args: []string{"stress-ng"}},
+	}, fake.Invocations())
+}
+
+func TestFakeRunnerFailStart(t *testing.T) {
+	startErr := errors.New("permission denied")
+	fake := NewFakeRunner()
+	fake.On("tc").FailStart(startErr)
+	defer SetRunner(fake)()
+
+	cs := NewCmdState(exec.Command("tc"))
+	defer RemoveCmdState(cs.Id)
+	assert.ErrorIs(t, cs.Start(), startErr)
+
+	_, err := cs.Stop(t.Context(), time.Second)
+	assert.Error(t, err)
+}
+
+func TestSetRunnerRestore(t *testing.T) {
+	first := NewFakeRunner()
+	restoreFirst := SetRunner(first)
+	restoreSecond := SetRunner(NewFakeRunner())
+	restoreSecond()
+	assert.Same(t, first, currentRunner())
+	restoreFirst()
+	assert.Nil(t, currentRunner())
+}
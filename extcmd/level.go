@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Stream identifies the output stream a line was written to.
+type Stream int
+
+const (
+	Stdout Stream = iota
+	Stderr
+
+	streamCount = iota
+)
+
+func (s Stream) String() string {
+	switch s {
+	case Stdout:
+		return "stdout"
+	case Stderr:
+		return "stderr"
+	default:
+		return "unknown"
+	}
+}
+
+// Levels of a Message, as understood by ActionKit and DiscoveryKit.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// Classifier determines the level of a line of output. It returns false if it cannot classify the line, the next
+// classifier is asked then. The line includes its trailing newline, if any.
+type Classifier func(stream Stream, line string) (level string, ok bool)
+
+// RegexClassifier classifies lines of the given streams matching the pattern with level. Without streams, lines of
+// all streams are considered.
+func RegexClassifier(pattern *regexp.Regexp, level string, streams ...Stream) Classifier {
+	return func(stream Stream, line string) (string, bool) {
+		if !streamMatches(streams, stream) {
+			return "", false
+		}
+		if !pattern.MatchString(line) {
+			return "", false
+		}
+		return level, true
+	}
+}
+
+// JsonLogClassifier classifies structured logs that are written as one JSON object per line, like zerolog, logrus or
+// slog do. The level is read from the first of the given keys that is present, "level" if none are given. Common
+// level names like "warning", "fatal" or "trace" are mapped to the levels understood by the agent.
+func JsonLogClassifier(keys ...string) Classifier {
+	if len(keys) == 0 {
+		keys = []string{"level"}
+	}
+	return func(stream Stream, line string) (string, bool) {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			return "", false
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return "", false
+		}
+		for _, key := range keys {
+			if value, ok := entry[key].(string); ok {
+				return normalizeLevel(value)
+			}
+		}
+		return "", false
+	}
+}
+
+func normalizeLevel(level string) (string, bool) {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return LevelDebug, true
+	case "info", "information", "notice":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error", "err", "fatal", "panic", "critical", "crit", "alert", "emergency":
+		return LevelError, true
+	default:
+		return "", false
+	}
+}
+
+// streamMatches reports whether stream is one of streams, an empty list matches all streams.
+func streamMatches(streams []Stream, stream Stream) bool {
+	return len(streams) == 0 || slices.Contains(streams, stream)
+}
+
+// classify returns the level of the first classifier that applies to the line, or the default level of the stream.
+func (cs *CmdState) classify(l Line) string {
+	for _, classifier := range cs.classifiers {
+		if level, ok := classifier(l.Stream, l.Text); ok {
+			return level
+		}
+	}
+	if l.Stream == Stderr {
+		if cs.stderrLevel != "" {
+			return cs.stderrLevel
+		}
+		return LevelWarn
+	}
+	return LevelInfo
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmdStateClassifiesLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		stream    Stream
+		line      string
+		wantLevel string
+	}{
+		{
+			name:      "stdout defaults to info",
+			stream:    Stdout,
+			line:      "hello\n",
+			wantLevel: LevelInfo,
+		},
+		{
+			name:      "stderr defaults to warn",
+			stream:    Stderr,
+			line:      "hello\n",
+			wantLevel: LevelWarn,
+		},
+		{
+			name:      "configured stderr level",
+			opts:      Options{StderrLevel: LevelError},
+			stream:    Stderr,
+			line:      "hello\n",
+			wantLevel: LevelError,
+		},
+		{
+			name:      "regex",
+			opts:      Options{Classifiers: []Classifier{RegexClassifier(regexp.MustCompile(`(?i)\berror\b`), LevelError)}},
+			stream:    Stdout,
+			line:      "ERROR: failed\n",
+			wantLevel: LevelError,
+		},
+		{
+			name:      "regex limited to other stream",
+			opts:      Options{Classifiers: []Classifier{RegexClassifier(regexp.MustCompile(`error`), LevelError, Stderr)}},
+			stream:    Stdout,
+			line:      "error\n",
+			wantLevel: LevelInfo,
+		},
+		{
+			name: "first classifier wins",
+			opts: Options{Classifiers: []Classifier{
+				RegexClassifier(regexp.MustCompile(`^DEBUG`), LevelDebug),
+				RegexClassifier(regexp.MustCompile(`failed`), LevelError),
+			}},
+			stream:    Stderr,
+			line:      "DEBUG failed to connect, retrying\n",
+			wantLevel: LevelDebug,
+		},
+		{
+			name:      "json log",
+			opts:      Options{Classifiers: []Classifier{JsonLogClassifier()}},
+			stream:    Stderr,
+			line:      `{"level":"INFO","msg":"started"}` + "\n",
+			wantLevel: LevelInfo,
+		},
+		{
+			name:      "json log with mapped level",
+			opts:      Options{Classifiers: []Classifier{JsonLogClassifier("severity", "level")}},
+			stream:    Stdout,
+			line:      `{"severity":"fatal","msg":"boom"}`,
+			wantLevel: LevelError,
+		},
+		{
+			name:      "json log with unknown level",
+			opts:      Options{Classifiers: []Classifier{JsonLogClassifier()}},
+			stream:    Stderr,
+			line:      `{"level":"verbose"}` + "\n",
+			wantLevel: LevelWarn,
+		},
+		{
+			name:      "not a json log",
+			opts:      Options{Classifiers: []Classifier{JsonLogClassifier()}},
+			stream:    Stdout,
+			line:      "{level: error\n",
+			wantLevel: LevelInfo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &CmdState{
+				mu:          new(sync.Mutex),
+				out:         newOutputBuffer(tt.opts),
+				classifiers: tt.opts.Classifiers,
+				stderrLevel: tt.opts.StderrLevel,
+			}
+			_, _ = cs.write(tt.stream, []byte(tt.line))
+
+			messages := cs.GetMessages(true)
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, tt.line, messages[0].Message)
+				assert.Equal(t, tt.wantLevel, *messages[0].Level)
+			}
+		})
+	}
+}
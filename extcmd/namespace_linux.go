@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// Namespace is a type of Linux namespace, see namespaces(7).
+type Namespace string
+
+const (
+	NamespaceCgroup Namespace = "cgroup"
+	NamespaceIPC    Namespace = "ipc"
+	NamespaceMount  Namespace = "mnt"
+	NamespaceNet    Namespace = "net"
+	NamespacePID    Namespace = "pid"
+	NamespaceTime   Namespace = "time"
+	NamespaceUser   Namespace = "user"
+	NamespaceUTS    Namespace = "uts"
+)
+
+// NsenterPath is the nsenter binary used by NamespacedCommand.
+var NsenterPath = "nsenter"
+
+var nsenterFlags = map[Namespace]string{
+	NamespaceCgroup: "--cgroup",
+	NamespaceIPC:    "--ipc",
+	NamespaceMount:  "--mount",
+	NamespaceNet:    "--net",
+	NamespacePID:    "--pid",
+	NamespaceTime:   "--time",
+	NamespaceUser:   "--user",
+	NamespaceUTS:    "--uts",
+}
+
+// NamespacedCommand returns a command that runs name with the given arguments inside the namespaces of the target
+// process, e.g., of a container, through nsenter(1). With NamespaceMount, name is resolved in the target's mount
+// namespace. With NamespacePID, nsenter forks the command as its child, which remains in the process group started by
+// CmdState.Start, so that it is stopped along with nsenter.
+//
+// The command is used like one created by exec.Command, e.g., through NewCmdState and CmdState.Start. Entering the
+// namespaces requires CAP_SYS_ADMIN and, for the PID, the permission to ptrace the target. An error is returned for
+// namespaces other than the ones declared by this package.
+func NamespacedCommand(target int, namespaces []Namespace, name string, arg ...string) (*exec.Cmd, error) {
+	args := []string{"--target", strconv.Itoa(target)}
+	for _, namespace := range namespaces {
+		flag, ok := nsenterFlags[namespace]
+		if !ok {
+			return nil, fmt.Errorf("unknown namespace %q", namespace)
+		}
+		args = append(args, flag)
+	}
+	args = append(args, "--", name)
+	args = append(args, arg...)
+	return exec.Command(NsenterPath, args...), nil
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacedCommandArgs(t *testing.T) {
+	cmd, err := NamespacedCommand(42, []Namespace{NamespaceNet, NamespaceMount}, "tc", "qdisc", "show")
+	require.NoError(t, err)
+	assert.Equal(t, []string{NsenterPath, "--target", "42", "--net", "--mount", "--", "tc", "qdisc", "show"}, cmd.Args)
+}
+
+func TestNamespacedCommandRejectsUnknownNamespace(t *testing.T) {
+	_, err := NamespacedCommand(42, []Namespace{NamespaceNet, "network"}, "tc", "qdisc", "show")
+	assert.EqualError(t, err, `unknown namespace "network"`)
+}
+
+func TestNamespacedCommandRunsInNamespaces(t *testing.T) {
+	if _, err := exec.LookPath(NsenterPath); err != nil || os.Geteuid() != 0 {
+		t.Skip("requires root and nsenter")
+	}
+	// The target runs in a network namespace of its own.
+	target := exec.Command("unshare", "--net", "sleep", "60")
+	if err := target.Start(); err != nil {
+		t.Skipf("cannot create network namespace: %v", err)
+	}
+	defer func() {
+		_ = target.Process.Kill()
+		_ = target.Wait()
+	}()
+	var net string
+	require.Eventually(t, func() bool {
+		net, _ = os.Readlink(fmt.Sprintf("/proc/%d/ns/net", target.Process.Pid))
+		own, _ := os.Readlink("/proc/self/ns/net")
+		return net != "" && net != own
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cmd, err := NamespacedCommand(target.Process.Pid, []Namespace{NamespaceNet, NamespacePID}, "sh", "-c", "readlink /proc/self/ns/net; sleep 60")
+	require.NoError(t, err)
+	cs := NewCmdState(cmd)
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	for l := range cs.Subscribe().Follow(ctx) {
+		assert.Equal(t, net+"\n", l.Text)
+		break
+	}
+	graceful, err := cs.Stop(ctx, 5*time.Second)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"bytes"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxOutputBytes is the amount of output a CmdState retains unless configured otherwise through
+// Options.MaxOutputBytes.
+const DefaultMaxOutputBytes = 1 << 20
+
+// outputBuffer retains the most recent output of a command. Complete lines are kept in a queue bounded by a maximum
+// number of bytes and lines, the oldest lines are dropped first. Lines are numbered consecutively, so that dropped lines
+// can be told apart from lines that have already been read.
+type outputBuffer struct {
+	maxBytes int
+	maxLines int
+
+	lines []Line
+	head  int
+	size  int
+	// partial holds the line that is not terminated yet, separately for each stream.
+	partial [streamCount]partialLine
+
+	// first is the number of the oldest retained line, next the number of the next line to be completed.
+	first uint64
+	next  uint64
+	// cursor is the number of the next line to be returned by read.
+	cursor uint64
+
+	droppedLines      int
+	droppedBytes      int64
+	unreportedDropped int
+
+	// changed is closed when output is written, see changes.
+	changed chan struct{}
+}
+
+// Line is a line of output.
+type Line struct {
+	// Stream is the stream the line was written to.
+	Stream Stream
+	// Text is the line including its trailing newline. It is missing if the line has not been terminated yet.
+	Text string
+	// Time is the time the line was completed.
+	Time time.Time
+}
+
+type partialLine struct {
+	text []byte
+	// cut is set if the beginning of the line has been dropped.
+	cut bool
+}
+
+func newOutputBuffer(opts Options) *outputBuffer {
+	maxBytes := opts.MaxOutputBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxOutputBytes
+	}
+	return &outputBuffer{maxBytes: maxBytes, maxLines: opts.MaxOutputLines}
+}
+
+func (b *outputBuffer) write(stream Stream, p []byte) (int, error) {
+	n := len(p)
+	partial := &b.partial[stream]
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			partial.text = append(partial.text, p...)
+			b.size += len(p)
+			break
+		}
+		text := string(partial.text) + string(p[:i+1])
+		b.size += i + 1
+		partial.text = partial.text[:0]
+		partial.cut = false
+		b.lines = append(b.lines, Line{Stream: stream, Text: text, Time: time.Now()})
+		b.next++
+		p = p[i+1:]
+	}
+	b.trim()
+	if b.changed != nil && n > 0 {
+		close(b.changed)
+		b.changed = nil
+	}
+	return n, nil
+}
+
+// trim drops the oldest output until the limits are met.
+func (b *outputBuffer) trim() {
+	for b.len() > 0 && (b.maxLines > 0 && b.len() > b.maxLines || b.maxBytes > 0 && b.size > b.maxBytes) {
+		text := b.lines[b.head].Text
+		b.lines[b.head] = Line{}
+		b.head++
+		b.size -= len(text)
+		if b.first >= b.cursor {
+			b.droppedLines++
+			b.unreportedDropped++
+			b.droppedBytes += int64(len(text))
+		}
+		b.first++
+	}
+	if b.first > b.cursor {
+		b.cursor = b.first
+	}
+
+	for stream := range b.partial {
+		if b.maxBytes <= 0 || b.size <= b.maxBytes {
+			break
+		}
+		// The partial lines alone exceed the limit, keep their ends.
+		partial := &b.partial[stream]
+		cut := min(b.size-b.maxBytes, len(partial.text))
+		for cut < len(partial.text) && !utf8.RuneStart(partial.text[cut]) {
+			cut++
+		}
+		if cut == 0 {
+			continue
+		}
+		partial.text = append(partial.text[:0], partial.text[cut:]...)
+		b.size -= cut
+		b.droppedBytes += int64(cut)
+		if !partial.cut {
+			partial.cut = true
+			b.droppedLines++
+			b.unreportedDropped++
+		}
+	}
+
+	if b.head > 0 && b.head >= len(b.lines)/2 {
+		b.lines = append(b.lines[:0], b.lines[b.head:]...)
+		b.head = 0
+	}
+}
+
+func (b *outputBuffer) len() int {
+	return len(b.lines) - b.head
+}
+
+// read returns the lines that have not been read yet, optionally followed by the partial lines, and the number of lines
+// dropped since the last call. The partial lines are returned again by later calls until they are complete.
+func (b *outputBuffer) read(includePartial bool) ([]Line, int) {
+	var result []Line
+	if unread := b.next - b.cursor; unread > 0 {
+		start := b.head + int(b.cursor-b.first)
+		result = append(result, b.lines[start:start+int(unread)]...)
+		b.cursor = b.next
+	}
+	if includePartial {
+		result = append(result, b.partialLines()...)
+	}
+	dropped := b.unreportedDropped
+	b.unreportedDropped = 0
+	return result, dropped
+}
+
+// since returns the retained lines starting at line number cursor, the number of lines that have been dropped before,
+// and the cursor to continue with.
+func (b *outputBuffer) since(cursor uint64) ([]Line, int, uint64) {
+	missed := 0
+	if cursor < b.first {
+		missed = int(b.first - cursor)
+		cursor = b.first
+	}
+	start := b.head + int(cursor-b.first)
+	return slices.Clone(b.lines[start:]), missed, b.next
+}
+
+// partialLines returns the lines that are not terminated yet.
+func (b *outputBuffer) partialLines() []Line {
+	var result []Line
+	for stream, partial := range b.partial {
+		if len(partial.text) > 0 {
+			result = append(result, Line{Stream: Stream(stream), Text: string(partial.text), Time: time.Now()})
+		}
+	}
+	return result
+}
+
+// changes returns a channel that is closed once output is written.
+func (b *outputBuffer) changes() <-chan struct{} {
+	if b.changed == nil {
+		b.changed = make(chan struct{})
+	}
+	return b.changed
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrProcessGone is returned by CmdState.Stop for a command rehydrated from a previous run of the extension whose
+// process no longer exists.
+var ErrProcessGone = errors.New("process is gone")
+
+// rehydratedPollInterval is the interval in which rehydrated commands are checked for having exited.
+const rehydratedPollInterval = 100 * time.Millisecond
+
+var (
+	stateDirMu sync.Mutex
+	stateDir   string
+)
+
+// record is the persisted metadata of a command.
+type record struct {
+	Id           string    `json:"id"`
+	Pid          int       `json:"pid"`
+	StartTime    time.Time `json:"startTime"`
+	StartTicks   uint64    `json:"startTicks,omitempty"`
+	Path         string    `json:"path"`
+	Args         []string  `json:"args"`
+	ProcessGroup bool      `json:"processGroup"`
+}
+
+// EnablePersistence persists the metadata of commands started through CmdState.Start to dir, so that they can still be
+// stopped after the extension restarted, e.g., processes started in other namespaces that survive the extension. The
+// metadata is removed once the command exited or its state is removed.
+//
+// The states persisted by a previous run are rehydrated, registered and returned. They are configured through opts,
+// but only support Stop, Wait and ExitCode, which stays -1. Their output is lost. The process of a rehydrated state is
+// identified by its PID and, on Linux, its start time, so that a process reusing the PID is not mistaken for it. On
+// Linux, the process group of a rehydrated state is stopped as long as it has members, even if its process is gone.
+func EnablePersistence(dir string, opts Options) ([]*CmdState, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	stateDirMu.Lock()
+	stateDir = dir
+	stateDirMu.Unlock()
+
+	var result []*CmdState
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		state, err := rehydrate(path, opts)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("failed to rehydrate command state, discarding it")
+			_ = os.Remove(path)
+			continue
+		}
+		log.Info().
+			Str("id", state.Id).
+			Int("pid", state.process.Load().Pid).
+			Bool("alive", state.alive()).
+			Msgf("rehydrated command %s", state.Cmd.Path)
+		result = append(result, state)
+	}
+	return result, nil
+}
+
+func rehydrate(path string, opts Options) (*CmdState, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	if err := json.Unmarshal(content, &r); err != nil {
+		return nil, err
+	}
+	if r.Id == "" || r.Pid <= 0 {
+		return nil, errors.New("incomplete record")
+	}
+	process, err := os.FindProcess(r.Pid)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &exec.Cmd{Path: r.Path, Args: r.Args}
+	if r.ProcessGroup {
+		prepareProcessGroup(cmd)
+	}
+	state := newCmdState(r.Id, cmd, opts)
+	state.rehydrated = true
+	state.startTime = r.StartTime
+	state.startTicks = r.StartTicks
+	state.process.Store(process)
+
+	states.Store(state.Id, state)
+	state.startReaping()
+	go func() { _ = state.Wait() }()
+	return state, nil
+}
+
+// persist writes the metadata of the started command to the state directory, if persistence is enabled.
+func (cs *CmdState) persist() {
+	stateDirMu.Lock()
+	dir := stateDir
+	stateDirMu.Unlock()
+	if dir == "" {
+		return
+	}
+
+	content, err := json.Marshal(record{
+		Id:           cs.Id,
+		Pid:          cs.Cmd.Process.Pid,
+		StartTime:    cs.startTime,
+		StartTicks:   cs.startTicks,
+		Path:         cs.Cmd.Path,
+		Args:         cs.Cmd.Args,
+		ProcessGroup: ownsProcessGroup(cs.Cmd),
+	})
+	if err == nil {
+		// Write atomically, so that a crash does not leave a partial record behind.
+		tmp := filepath.Join(dir, "."+cs.Id+".tmp")
+		if err = os.WriteFile(tmp, content, 0o600); err == nil {
+			err = os.Rename(tmp, filepath.Join(dir, cs.Id+".json"))
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("id", cs.Id).Msg("failed to persist command state")
+	}
+}
+
+func removeRecord(id string) {
+	stateDirMu.Lock()
+	dir := stateDir
+	stateDirMu.Unlock()
+	if dir == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Warn().Err(err).Str("id", id).Msg("failed to remove persisted command state")
+	}
+}
+
+// alive reports whether the process of a rehydrated command, or a process remaining in its process group, still
+// exists.
+func (cs *CmdState) alive() bool {
+	pid := cs.process.Load().Pid
+	return processAlive(pid, cs.startTicks) || ownsProcessGroup(cs.Cmd) && processGroupAlive(pid, cs.startTicks)
+}
+
+// waitRehydrated blocks until the process of a rehydrated command and its process group are gone. It is not a child of
+// the extension, so it cannot be waited for.
+func (cs *CmdState) waitRehydrated() {
+	ticker := time.NewTicker(rehydratedPollInterval)
+	defer ticker.Stop()
+	for cs.alive() {
+		<-ticker.C
+	}
+}
+
+// signal sends sig to the process group of the command. Commands started through a Runner are signaled through their
+// Process, rehydrated commands through signalRehydrated.
+func (cs *CmdState) signal(sig os.Signal) error {
+	if p := cs.runnerProcess.Load(); p != nil {
+		if err := (*p).Signal(sig); !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		return nil
+	}
+	if cs.rehydrated {
+		if err := cs.signalRehydrated(sig); !errors.Is(err, ErrProcessGone) {
+			return err
+		}
+		return nil
+	}
+	cs.reapMu.Lock()
+	defer cs.reapMu.Unlock()
+	if cs.reaped {
+		return nil
+	}
+	return signalProcessGroup(cs.Cmd, cs.startedProcess(), sig)
+}
+
+// signalRehydrated sends sig to the process group of a rehydrated command while it has members, even if the process
+// of the command is gone. Otherwise, the process is signaled if it is still the same. ErrProcessGone is returned if
+// neither exists anymore.
+func (cs *CmdState) signalRehydrated(sig os.Signal) error {
+	process := cs.process.Load()
+	if ownsProcessGroup(cs.Cmd) && processGroupAlive(process.Pid, cs.startTicks) {
+		return signalProcessGroup(cs.Cmd, process, sig)
+	}
+	if err := signalProcess(process, cs.startTicks, sig); errors.Is(err, os.ErrProcessDone) {
+		return ErrProcessGone
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cs *CmdState) kill() {
+	if p := cs.runnerProcess.Load(); p != nil {
+		_ = (*p).Signal(os.Kill)
+		return
+	}
+	if cs.rehydrated {
+		_ = cs.signalRehydrated(os.Kill)
+		return
+	}
+	cs.reapMu.Lock()
+	defer cs.reapMu.Unlock()
+	if !cs.reaped {
+		killProcessGroup(cs.Cmd, cs.startedProcess())
+	}
+}
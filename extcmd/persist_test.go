@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !windows
+
+package extcmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enablePersistence(t *testing.T, dir string) []*CmdState {
+	t.Helper()
+	t.Cleanup(func() {
+		stateDirMu.Lock()
+		defer stateDirMu.Unlock()
+		stateDir = ""
+	})
+
+	rehydrated, err := EnablePersistence(dir, Options{StopGracePeriod: time.Second})
+	require.NoError(t, err)
+	for _, state := range rehydrated {
+		t.Cleanup(func() { RemoveCmdState(state.Id) })
+	}
+	return rehydrated
+}
+
+func writeRecord(t *testing.T, dir string, r record) {
+	t.Helper()
+	content, err := json.Marshal(r)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, r.Id+".json"), content, 0o600))
+}
+
+func TestCmdStateIsPersisted(t *testing.T) {
+	dir := t.TempDir()
+	enablePersistence(t, dir)
+
+	cs := NewCmdState(exec.Command("sleep", "60"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+
+	content, err := os.ReadFile(filepath.Join(dir, cs.Id+".json"))
+	require.NoError(t, err)
+	var r record
+	require.NoError(t, json.Unmarshal(content, &r))
+	assert.Equal(t, cs.Id, r.Id)
+	assert.Equal(t, cs.Cmd.Process.Pid, r.Pid)
+	assert.Equal(t, []string{"sleep", "60"}, r.Args)
+	assert.True(t, r.ProcessGroup)
+	assert.WithinDuration(t, time.Now(), r.StartTime, 10*time.Second)
+	if runtime.GOOS == "linux" {
+		assert.NotZero(t, r.StartTicks)
+	}
+
+	_, err = cs.Stop(t.Context(), time.Second)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, cs.Id+".json"), "the record is removed once the command exited")
+}
+
+func TestRehydratedCmdStateCanBeStopped(t *testing.T) {
+	// The command of the "previous run" is simulated by a command started by this test.
+	cs := NewCmdState(exec.Command("sleep", "60"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	startTicks, _ := processStartTicks(cs.Cmd.Process.Pid)
+
+	dir := t.TempDir()
+	writeRecord(t, dir, record{
+		Id:           "rehydrated",
+		Pid:          cs.Cmd.Process.Pid,
+		StartTicks:   startTicks,
+		Path:         cs.Cmd.Path,
+		Args:         cs.Cmd.Args,
+		ProcessGroup: true,
+	})
+	rehydrated := enablePersistence(t, dir)
+	require.Len(t, rehydrated, 1)
+	state, err := GetCmdState("rehydrated")
+	require.NoError(t, err)
+	assert.Same(t, rehydrated[0], state)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	graceful, err := state.Stop(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+	assert.Equal(t, -1, cs.ExitCode(), "terminated by a signal")
+	assert.NoFileExists(t, filepath.Join(dir, "rehydrated.json"))
+}
+
+func TestRehydratedCmdStateStopsRemainingProcessGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process groups of rehydrated commands are only tracked on Linux")
+	}
+	// The shell of the "previous run" exits right away, the background process remains in its group.
+	cs := NewCmdState(exec.Command("sh", "-c", "sleep 60 >/dev/null 2>&1 & echo $!"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	startTicks, err := processStartTicks(cs.Cmd.Process.Pid)
+	require.NoError(t, err)
+	require.NoError(t, cs.Wait())
+	lines := cs.GetLines(true)
+	require.Len(t, lines, 1)
+	sleepPid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeRecord(t, dir, record{
+		Id:           "group",
+		Pid:          cs.Cmd.Process.Pid,
+		StartTicks:   startTicks,
+		Path:         cs.Cmd.Path,
+		Args:         cs.Cmd.Args,
+		ProcessGroup: true,
+	})
+	rehydrated := enablePersistence(t, dir)
+	require.Len(t, rehydrated, 1)
+	assert.True(t, rehydrated[0].alive())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	graceful, err := rehydrated[0].Stop(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+	assert.False(t, processAlive(sleepPid, 0), "the remaining process is stopped")
+}
+
+func TestRehydratedCmdStateReportsGoneProcess(t *testing.T) {
+	exited := exec.Command("true")
+	require.NoError(t, exited.Run())
+
+	dir := t.TempDir()
+	writeRecord(t, dir, record{Id: "gone", Pid: exited.Process.Pid, Path: exited.Path, Args: exited.Args})
+	if runtime.GOOS == "linux" {
+		// The PID of a process group leader with a different start time, as if the PID had been reused.
+		reused := NewCmdState(exec.Command("sleep", "60"))
+		defer RemoveCmdState(reused.Id)
+		require.NoError(t, reused.Start())
+		defer reused.kill()
+		startTicks, err := processStartTicks(reused.Cmd.Process.Pid)
+		require.NoError(t, err)
+		writeRecord(t, dir, record{Id: "reused", Pid: reused.Cmd.Process.Pid, StartTicks: startTicks + 1, ProcessGroup: true})
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "malformed.json"), []byte("{"), 0o600))
+
+	rehydrated := enablePersistence(t, dir)
+	assert.NoFileExists(t, filepath.Join(dir, "malformed.json"))
+	require.NotEmpty(t, rehydrated)
+	for _, state := range rehydrated {
+		_, err := state.Stop(t.Context(), time.Second)
+		assert.ErrorIs(t, err, ErrProcessGone, state.Id)
+		assert.NoError(t, state.Wait())
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitExited blocks until the child process with the given PID exited, but leaves it waitable, so that its PID cannot
+// be reused until it is reaped. It returns false if the process cannot be waited for.
+func waitExited(pid int) bool {
+	for {
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WEXITED|unix.WNOWAIT, nil)
+		if !errors.Is(err, unix.EINTR) {
+			return err == nil
+		}
+	}
+}
+
+// signalProcess sends sig to the process, if it is still the one started at startTicks, see processStartTicks. The
+// process is signaled through a pidfd, so that its PID cannot be reused between the check and the signal. It returns
+// os.ErrProcessDone if the process is gone.
+func signalProcess(process *os.Process, startTicks uint64, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return errors.New("unsupported signal")
+	}
+	fd, err := unix.PidfdOpen(process.Pid, 0)
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	} else if err != nil {
+		// pidfds are available as of Linux 5.3.
+		if !processAlive(process.Pid, startTicks) {
+			return os.ErrProcessDone
+		}
+		return process.Signal(sig)
+	}
+	defer unix.Close(fd)
+
+	if !processAlive(process.Pid, startTicks) {
+		return os.ErrProcessDone
+	}
+	if err := unix.PidfdSendSignal(fd, s, nil, 0); errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// processGroupAlive reports whether the process group still has members started no earlier than startTicks, see
+// processStartTicks. Older members belong to another group that reused the ID of the group.
+func processGroupAlive(pgid int, startTicks uint64) bool {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		pgrp, ticks, err := processStat(pid)
+		if err == nil && pgrp == pgid && ticks >= startTicks {
+			return true
+		}
+	}
+	return false
+}
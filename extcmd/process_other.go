@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !linux
+
+package extcmd
+
+import "os"
+
+// waitExited is only supported on Linux, the command is reaped right away on other platforms.
+func waitExited(int) bool {
+	return false
+}
+
+// signalProcess sends sig to the process, if it still exists. It returns os.ErrProcessDone if the process is gone.
+func signalProcess(process *os.Process, startTicks uint64, sig os.Signal) error {
+	if !processAlive(process.Pid, startTicks) {
+		return os.ErrProcessDone
+	}
+	return process.Signal(sig)
+}
+
+// processGroupAlive is only supported on Linux, only the process of a rehydrated command is tracked on other
+// platforms.
+func processGroupAlive(int, uint64) bool {
+	return false
+}
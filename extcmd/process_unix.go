@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !windows
+
+package extcmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+var defaultStopSignal os.Signal = syscall.SIGTERM
+
+// prepareProcessGroup makes the command the leader of a new process group, unless configured otherwise.
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if !cmd.SysProcAttr.Setsid && !cmd.SysProcAttr.Setpgid {
+		cmd.SysProcAttr.Setpgid = true
+	}
+}
+
+// ownsProcessGroup reports whether the command is the leader of its process group, so that the group can be signaled
+// without affecting other processes.
+func ownsProcessGroup(cmd *exec.Cmd) bool {
+	attr := cmd.SysProcAttr
+	return attr != nil && (attr.Setsid || attr.Setpgid && attr.Pgid == 0)
+}
+
+func signalProcessGroup(cmd *exec.Cmd, process *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok || !ownsProcessGroup(cmd) {
+		return ignoreProcessDone(process.Signal(sig))
+	}
+	return ignoreProcessDone(syscall.Kill(-process.Pid, s))
+}
+
+// killProcessGroup kills the command and the processes remaining in its process group. Errors are ignored, the group
+// usually is gone already.
+func killProcessGroup(cmd *exec.Cmd, process *os.Process) {
+	_ = signalProcessGroup(cmd, process, syscall.SIGKILL)
+}
+
+func ignoreProcessDone(err error) error {
+	if errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
+
+// processAlive reports whether the process with the given PID exists. If startTicks is known, it must match the start
+// time of the process, see processStartTicks.
+func processAlive(pid int, startTicks uint64) bool {
+	ticks, err := processStartTicks(pid)
+	if err == nil {
+		return startTicks == 0 || ticks == startTicks
+	}
+	if !errors.Is(err, errors.ErrUnsupported) {
+		return false
+	}
+	err = syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processUsage returns the signal that terminated the process, if any, and its maximum resident set size in bytes.
+func processUsage(state *os.ProcessState) (os.Signal, int64) {
+	var sig os.Signal
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		sig = status.Signal()
+	}
+	var maxRSS int64
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		maxRSS = int64(usage.Maxrss)
+		// Darwin reports bytes, the other platforms kilobytes.
+		if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+			maxRSS *= 1024
+		}
+	}
+	return sig, maxRSS
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !windows
+
+package extcmd
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdStateStop(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		opts         Options
+		wantGraceful bool
+	}{
+		{
+			name:         "graceful",
+			script:       "sleep 60",
+			wantGraceful: true,
+		},
+		{
+			name:         "configured signal",
+			script:       `trap "exit 3" USR1; sleep 60 & wait`,
+			opts:         Options{StopSignal: syscall.SIGUSR1},
+			wantGraceful: true,
+		},
+		{
+			name:         "signal ignored",
+			script:       `trap "" TERM; sleep 60`,
+			wantGraceful: false,
+		},
+		{
+			// The child inherits the ignored signal and keeps the output open, so the command only completes once the
+			// whole process group is killed.
+			name:         "child processes",
+			script:       `trap "" TERM; sleep 60 & wait`,
+			wantGraceful: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := NewCmdStateWithOptions(exec.Command("sh", "-c", tt.script), tt.opts)
+			defer RemoveCmdState(cs.Id)
+			require.NoError(t, cs.Start())
+			// Give the shell the chance to install its traps.
+			time.Sleep(100 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			graceful, err := cs.Stop(ctx, 200*time.Millisecond)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGraceful, graceful)
+			assert.NotEqual(t, 0, cs.ExitCode())
+		})
+	}
+}
+
+func TestCmdStateStopAfterExit(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", "exit 4"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	require.Error(t, cs.Wait())
+
+	graceful, err := cs.Stop(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+	assert.Equal(t, 4, cs.ExitCode())
+}
+
+func TestCmdStateStopKillsRemainingChildProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("remaining child processes are only killed on Linux")
+	}
+	// The command exits on the stop signal, while its child ignores it and keeps the output open.
+	cs := NewCmdState(exec.Command("sh", "-c", `trap "exit 0" TERM; (trap "" TERM; sleep 60) & wait`))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	graceful, err := cs.Stop(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+}
+
+func TestCmdStateStopDoesNotSignalTheGroupAfterExit(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", "sleep 60 >/dev/null 2>&1 & echo $!"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	require.NoError(t, cs.Wait())
+	pid, err := strconv.Atoi(strings.TrimSpace(strings.Join(cs.GetLines(false), "")))
+	require.NoError(t, err)
+	defer func() { _ = syscall.Kill(pid, syscall.SIGKILL) }()
+
+	graceful, err := cs.Stop(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.True(t, graceful)
+	// The group is left alone once the command was reaped, as its PID may have been reused.
+	assert.NoError(t, syscall.Kill(pid, 0))
+}
+
+func TestCmdStateStopKillsOnContextCancellation(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", `trap "" TERM; sleep 60`))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	graceful, err := cs.Stop(ctx, time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, graceful)
+
+	_ = cs.Wait()
+	assert.Equal(t, -1, cs.ExitCode())
+}
+
+func TestCmdStateStartTwice(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", "exit 0"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	assert.Error(t, cs.Start())
+	assert.NoError(t, cs.Wait())
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build windows
+
+package extcmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var defaultStopSignal = os.Interrupt
+
+// prepareProcessGroup is a no-op, Windows has no process groups that could be signaled.
+func prepareProcessGroup(*exec.Cmd) {}
+
+func signalProcessGroup(_ *exec.Cmd, process *os.Process, sig os.Signal) error {
+	if err := process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// killProcessGroup kills the command. Child processes are not affected.
+func killProcessGroup(_ *exec.Cmd, process *os.Process) {
+	_ = process.Kill()
+}
+
+// ownsProcessGroup always returns false, Windows has no process groups that could be signaled.
+func ownsProcessGroup(*exec.Cmd) bool {
+	return false
+}
+
+// processAlive reports whether the process with the given PID exists.
+func processAlive(pid int, _ uint64) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
+
+// processUsage returns no signal and resident set size, they are not known on Windows.
+func processUsage(*os.ProcessState) (os.Signal, int64) {
+	return nil, 0
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// processStartTicks returns the start time of the process in clock ticks after boot, see proc_pid_stat(5). Together
+// with the PID, it identifies a process, even if the PID is reused later. Zombies are reported as gone.
+func processStartTicks(pid int) (uint64, error) {
+	_, ticks, err := processStat(pid)
+	return ticks, err
+}
+
+// processStat returns the process group and the start time in clock ticks of the process, see processStartTicks.
+func processStat(pid int) (pgrp int, startTicks uint64, err error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name in the second field may contain spaces and parentheses.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, 0, errors.New("malformed stat")
+	}
+	fields := bytes.Fields(stat[i+1:])
+	// fields[0] is the state (field 3), the process group is field 5 and the start time field 22.
+	if len(fields) < 20 {
+		return 0, 0, errors.New("malformed stat")
+	}
+	if string(fields[0]) == "Z" {
+		return 0, 0, os.ErrProcessDone
+	}
+	if pgrp, err = strconv.Atoi(string(fields[2])); err != nil {
+		return 0, 0, err
+	}
+	startTicks, err = strconv.ParseUint(string(fields[19]), 10, 64)
+	return pgrp, startTicks, err
+}
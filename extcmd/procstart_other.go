@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !linux
+
+package extcmd
+
+import "errors"
+
+// processStartTicks is only supported on Linux.
+func processStartTicks(int) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ReapHook is called after a CmdState has been reaped, see Options.IdleTimeout.
+type ReapHook func(state *CmdState)
+
+var (
+	reapHooksMu sync.Mutex
+	reapHooks   []ReapHook
+)
+
+// OnReap registers a hook that is called whenever a CmdState is reaped because it has not been polled within its idle
+// timeout. Hooks are called after the command has been stopped and the state has been removed, e.g., to clean up
+// bookkeeping that refers to the state.
+func OnReap(hook ReapHook) {
+	reapHooksMu.Lock()
+	defer reapHooksMu.Unlock()
+	reapHooks = append(reapHooks, hook)
+}
+
+// touch records that the state has been polled.
+func (cs *CmdState) touch() {
+	cs.lastPolled.Store(time.Now().UnixNano())
+}
+
+// startReaping arms the timer that reaps the state once it has not been polled for the idle timeout.
+func (cs *CmdState) startReaping() {
+	if cs.idleTimeout <= 0 {
+		return
+	}
+	cs.touch()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.reapTimer = time.AfterFunc(cs.idleTimeout, cs.reapIfIdle)
+}
+
+func (cs *CmdState) stopReaping() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.reapTimer != nil {
+		cs.reapTimer.Stop()
+	}
+}
+
+func (cs *CmdState) reapIfIdle() {
+	if state, ok := states.Load(cs.Id); !ok || state != cs {
+		return
+	}
+	idle := time.Since(time.Unix(0, cs.lastPolled.Load()))
+	if idle < cs.idleTimeout {
+		cs.mu.Lock()
+		cs.reapTimer.Reset(cs.idleTimeout - idle)
+		cs.mu.Unlock()
+		return
+	}
+	if !states.CompareAndDelete(cs.Id, cs) {
+		return
+	}
+
+	graceful := true
+	if cs.started() {
+		ctx, cancel := context.WithTimeout(context.Background(), cs.stopGracePeriod+time.Second)
+		defer cancel()
+		var err error
+		if graceful, err = cs.Stop(ctx, cs.stopGracePeriod); err != nil && !errors.Is(err, ErrProcessGone) {
+			log.Warn().Err(err).Str("id", cs.Id).Msg("failed to stop reaped command")
+		}
+	}
+	log.Info().
+		Str("id", cs.Id).
+		Dur("idle", idle).
+		Bool("graceful", graceful).
+		Msgf("reaped command %s, its state has not been polled within %s", cs.Cmd.Path, cs.idleTimeout)
+
+	reapHooksMu.Lock()
+	hooks := append([]ReapHook(nil), reapHooks...)
+	reapHooksMu.Unlock()
+	for _, hook := range hooks {
+		callReapHook(hook, cs)
+	}
+}
+
+// callReapHook invokes a hook, recovering from a panic so one misbehaving hook can't crash the extension.
+func callReapHook(hook ReapHook, cs *CmdState) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Str("id", cs.Id).Msgf("reap hook panicked: %v", r)
+		}
+	}()
+	hook(cs)
+}
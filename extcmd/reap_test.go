@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdStateIsReapedWhenIdle(t *testing.T) {
+	reaped := make(chan *CmdState, 1)
+	OnReap(func(state *CmdState) { reaped <- state })
+	defer func() { reapHooks = nil }()
+
+	cs := NewCmdStateWithOptions(exec.Command("sleep", "60"), Options{
+		IdleTimeout:     100 * time.Millisecond,
+		StopGracePeriod: time.Second,
+	})
+	require.NoError(t, cs.Start())
+
+	select {
+	case state := <-reaped:
+		assert.Same(t, cs, state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "command was not reaped")
+	}
+	_, err := GetCmdState(cs.Id)
+	assert.Error(t, err)
+	select {
+	case <-cs.done:
+	default:
+		assert.Fail(t, "command was not stopped")
+	}
+}
+
+func TestCmdStateIsNotReapedWhilePolled(t *testing.T) {
+	reaped := make(chan *CmdState, 1)
+	OnReap(func(state *CmdState) { reaped <- state })
+	defer func() { reapHooks = nil }()
+
+	cs := NewCmdStateWithOptions(exec.Command("sleep", "60"), Options{IdleTimeout: 200 * time.Millisecond})
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	defer cs.Stop(t.Context(), 0)
+
+	for range 10 {
+		time.Sleep(50 * time.Millisecond)
+		_, err := GetCmdState(cs.Id)
+		require.NoError(t, err)
+	}
+	assert.Empty(t, reaped)
+}
+
+func TestRemovedCmdStateIsNotReaped(t *testing.T) {
+	reaped := make(chan *CmdState, 1)
+	OnReap(func(state *CmdState) { reaped <- state })
+	defer func() { reapHooks = nil }()
+
+	cs := NewCmdStateWithOptions(exec.Command("sleep", "60"), Options{IdleTimeout: 50 * time.Millisecond})
+	RemoveCmdState(cs.Id)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Empty(t, reaped)
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+// Rlimit limits a resource of a command, see setrlimit(2).
+type Rlimit struct {
+	// Resource is the resource to limit, e.g., unix.RLIMIT_NOFILE.
+	Resource int
+	// Cur is the soft limit.
+	Cur uint64
+	// Max is the hard limit.
+	Max uint64
+}
+
+// CgroupOptions configures the cgroup v2 a command is placed into.
+type CgroupOptions struct {
+	// Parent is the cgroup in which a dedicated cgroup is created for each command, either absolute or relative to
+	// the cgroup v2 mount (/sys/fs/cgroup). The extension must be allowed to create child cgroups in it, and to enable
+	// the controllers required for the configured caps. Empty means the cgroup of the extension itself, which suits a
+	// cgroup delegated to the user of the extension. As cgroup v2 does not enable controllers for the children of
+	// cgroups with processes of their own, caps then require the extension to run in the root of a cgroup namespace.
+	Parent string
+	// CPUs caps the CPU time of the command to the given number of CPUs, e.g., 0.5 for half a CPU. Zero disables the
+	// cap.
+	CPUs float64
+	// MemoryMax caps the memory of the command in bytes. Zero disables the cap.
+	MemoryMax int64
+}
+
+// resources describes the resource limits of a command, which are applied by CmdState.Start.
+type resources struct {
+	rlimits []Rlimit
+	nice    int
+	cgroup  *CgroupOptions
+	// cgroupPath is the path of the cgroup created for the command.
+	cgroupPath string
+	// oomKilled is set by release if the OOM killer killed a process in the cgroup.
+	oomKilled bool
+}
+
+func newResources(opts Options) resources {
+	return resources{rlimits: opts.Rlimits, nice: opts.Nice, cgroup: opts.Cgroup}
+}
+
+func (r *resources) configured() bool {
+	return len(r.rlimits) > 0 || r.nice != 0 || r.cgroup != nil
+}
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sys/unix"
+)
+
+// cgroupRoot is the mount point of the cgroup v2 hierarchy.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cpuPeriod is the period in microseconds written to cpu.max.
+const cpuPeriod = 100000
+
+// cgroupRemoveTimeout limits the time release waits for the processes of a cgroup to be gone.
+const cgroupRemoveTimeout = time.Second
+
+// limitsEnv passes the rlimits and the nice value to the re-executed extension binary, see startWithLimits.
+const limitsEnv = "_EXTCMD_LIMITS"
+
+func init() {
+	if spec, ok := os.LookupEnv(limitsEnv); ok {
+		execWithLimits(spec)
+	}
+}
+
+// limitSpec is passed through limitsEnv.
+type limitSpec struct {
+	Path     string
+	StatusFd int
+	Rlimits  []Rlimit
+	Nice     int
+}
+
+// start starts the command with the resource limits applied. If the limits cannot be applied after the command has been
+// started, an error is returned while cmd.Process is set. The command then has to be killed.
+//
+// The command is placed into its cgroup when it is created, the rlimits and the nice value are applied before it is
+// executed, see startWithLimits.
+func (r *resources) start(cmd *exec.Cmd, name string) error {
+	if r.cgroup == nil {
+		return r.startWithLimits(cmd)
+	}
+
+	path, err := createCgroup(r.cgroup, name)
+	if err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	r.cgroupPath = path
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		r.release()
+		return fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = fd
+	if err := r.startWithLimits(cmd); err != nil {
+		if cmd.Process == nil {
+			r.release()
+		}
+		return err
+	}
+	return nil
+}
+
+// startWithLimits starts the command with the rlimits and the nice value applied. Go cannot apply them between fork and
+// exec, so the extension binary is executed instead, which applies them to itself in execWithLimits and then executes
+// the command. Errors are reported through a pipe that is closed once the command is executed.
+func (r *resources) startWithLimits(cmd *exec.Cmd) error {
+	if (len(r.rlimits) == 0 && r.nice == 0) || cmd.Err != nil {
+		return cmd.Start()
+	}
+
+	statusReader, statusWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer statusReader.Close()
+	spec, err := json.Marshal(limitSpec{Path: cmd.Path, StatusFd: 3 + len(cmd.ExtraFiles), Rlimits: r.rlimits, Nice: r.nice})
+	if err != nil {
+		_ = statusWriter.Close()
+		return err
+	}
+
+	path, args, env, extraFiles := cmd.Path, cmd.Args, cmd.Env, cmd.ExtraFiles
+	if len(args) == 0 {
+		cmd.Args = []string{path}
+	}
+	cmd.Path = "/proc/self/exe"
+	cmd.Env = append(cmd.Environ(), limitsEnv+"="+string(spec))
+	cmd.ExtraFiles = append(slices.Clip(extraFiles), statusWriter)
+	err = cmd.Start()
+	cmd.Path, cmd.Args, cmd.Env, cmd.ExtraFiles = path, args, env, extraFiles
+	_ = statusWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	status, err := io.ReadAll(statusReader)
+	if err != nil {
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	if len(status) > 0 {
+		return errors.New(string(status))
+	}
+	return nil
+}
+
+// execWithLimits applies the limits of spec to the current process and executes the command. It only returns if that
+// fails, in which case the error is reported through the status pipe and the process exits.
+func execWithLimits(spec string) {
+	var l limitSpec
+	if err := json.Unmarshal([]byte(spec), &l); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s: %v\n", limitsEnv, err)
+		os.Exit(127)
+	}
+	status := os.NewFile(uintptr(l.StatusFd), "status")
+	syscall.CloseOnExec(l.StatusFd)
+	// The nice value is a property of the thread, it must be the one executing the command.
+	runtime.LockOSThread()
+
+	err := l.apply()
+	if err == nil {
+		env := slices.DeleteFunc(os.Environ(), func(e string) bool { return strings.HasPrefix(e, limitsEnv+"=") })
+		err = syscall.Exec(l.Path, os.Args, env)
+		if err != nil {
+			err = fmt.Errorf("failed to execute %s: %w", l.Path, err)
+		}
+	}
+	_, _ = status.WriteString(err.Error())
+	os.Exit(127)
+}
+
+func (l *limitSpec) apply() error {
+	for _, rlimit := range l.Rlimits {
+		// syscall.Setrlimit, unlike unix.Setrlimit, keeps the runtime from restoring RLIMIT_NOFILE on exec.
+		if err := syscall.Setrlimit(rlimit.Resource, &syscall.Rlimit{Cur: rlimit.Cur, Max: rlimit.Max}); err != nil {
+			return fmt.Errorf("failed to set rlimit %d: %w", rlimit.Resource, err)
+		}
+	}
+	if l.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, l.Nice); err != nil {
+			return fmt.Errorf("failed to set nice value: %w", err)
+		}
+	}
+	return nil
+}
+
+// release kills the processes remaining in the cgroup of the command and removes it. If it cannot be removed, the cgroup
+// is left behind.
+func (r *resources) release() {
+	if r.cgroupPath == "" {
+		return
+	}
+	r.oomKilled = r.oomKilled || cgroupOOMKilled(r.cgroupPath)
+	// cgroup.kill is available as of Linux 5.14.
+	if err := writeCgroupFile(r.cgroupPath, "cgroup.kill", "1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Debug().Err(err).Str("cgroup", r.cgroupPath).Msg("failed to kill the processes in the cgroup")
+	}
+	// The killed processes leave the cgroup asynchronously.
+	err := os.Remove(r.cgroupPath)
+	for deadline := time.Now().Add(cgroupRemoveTimeout); errors.Is(err, unix.EBUSY) && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		err = os.Remove(r.cgroupPath)
+	}
+	if err != nil {
+		log.Debug().Err(err).Str("cgroup", r.cgroupPath).Msg("failed to remove cgroup")
+		return
+	}
+	r.cgroupPath = ""
+}
+
+// cgroupOOMKilled reports whether the OOM killer killed a process in the cgroup, see memory.events in cgroup-v2.
+func cgroupOOMKilled(cgroup string) bool {
+	content, err := os.ReadFile(filepath.Join(cgroup, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for line := range strings.Lines(string(content)) {
+		if count, ok := strings.CutPrefix(strings.TrimSpace(line), "oom_kill "); ok {
+			return count != "0"
+		}
+	}
+	return false
+}
+
+// createCgroup creates a child cgroup of opts.Parent with the configured caps.
+func createCgroup(opts *CgroupOptions, name string) (string, error) {
+	parent := opts.Parent
+	if parent == "" {
+		current, err := currentCgroup()
+		if err != nil {
+			return "", err
+		}
+		parent = filepath.Join(cgroupRoot, current)
+	} else if !filepath.IsAbs(parent) {
+		parent = filepath.Join(cgroupRoot, parent)
+	}
+
+	var controllers []string
+	if opts.CPUs > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if opts.MemoryMax > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(parent, name)
+	if err := os.Mkdir(path, 0o755); err != nil {
+		return "", err
+	}
+	var err error
+	if opts.CPUs > 0 {
+		quota := int64(math.Ceil(opts.CPUs * cpuPeriod))
+		err = writeCgroupFile(path, "cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod))
+	}
+	if err == nil && opts.MemoryMax > 0 {
+		err = writeCgroupFile(path, "memory.max", strconv.FormatInt(opts.MemoryMax, 10))
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// currentCgroup returns the cgroup v2 of the extension, relative to cgroupRoot, see cgroups(7).
+func currentCgroup() (string, error) {
+	content, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.Lines(string(content)) {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", errors.New("not in a cgroup v2")
+}
+
+// enableControllers enables the controllers for the children of the cgroup, unless they are already.
+func enableControllers(cgroup string, controllers []string) error {
+	content, err := os.ReadFile(filepath.Join(cgroup, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+	enabled := strings.Fields(string(content))
+	var missing []string
+	for _, controller := range controllers {
+		if !slices.Contains(enabled, controller) {
+			missing = append(missing, "+"+controller)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return writeCgroupFile(cgroup, "cgroup.subtree_control", strings.Join(missing, " "))
+}
+
+func writeCgroupFile(cgroup, file, value string) error {
+	f, err := os.OpenFile(filepath.Join(cgroup, file), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %q to %s: %w", value, filepath.Join(cgroup, file), err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build linux
+
+package extcmd
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestCmdStateAppliesRlimitsAndNice(t *testing.T) {
+	cs := NewCmdStateWithOptions(exec.Command("sh", "-c", "ulimit -n; nice"), Options{
+		Rlimits: []Rlimit{{Resource: unix.RLIMIT_NOFILE, Cur: 64, Max: 128}},
+		Nice:    5,
+	})
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	require.NoError(t, cs.Wait())
+
+	assert.Equal(t, []string{"64\n", "5\n"}, cs.GetLines(true))
+}
+
+func TestCmdStateFailsToApplyRlimits(t *testing.T) {
+	cs := NewCmdStateWithOptions(exec.Command("sleep", "60"), Options{
+		Rlimits: []Rlimit{{Resource: unix.RLIMIT_NOFILE, Cur: 2, Max: 1}},
+	})
+	defer RemoveCmdState(cs.Id)
+
+	require.ErrorContains(t, cs.Start(), "failed to set rlimit")
+	assert.Error(t, cs.Wait(), "the command is killed")
+}
+
+func TestEnableControllers(t *testing.T) {
+	cgroup := t.TempDir()
+	subtreeControl := filepath.Join(cgroup, "cgroup.subtree_control")
+	require.NoError(t, os.WriteFile(subtreeControl, []byte("cpu\n"), 0o644))
+
+	require.NoError(t, enableControllers(cgroup, []string{"cpu"}))
+	content, err := os.ReadFile(subtreeControl)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu\n", string(content), "enabled controllers are not written")
+
+	require.NoError(t, enableControllers(cgroup, []string{"cpu", "memory"}))
+	content, err = os.ReadFile(subtreeControl)
+	require.NoError(t, err)
+	assert.Equal(t, "+memory", string(content))
+}
+
+func TestCreateCgroupRemovesCgroupOnFailure(t *testing.T) {
+	defer func(previous string) { cgroupRoot = previous }(cgroupRoot)
+	cgroupRoot = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupRoot, "cgroup.subtree_control"), []byte("cpu memory\n"), 0o644))
+
+	// Outside of cgroupfs, the interface files do not exist.
+	_, err := createCgroup(&CgroupOptions{CPUs: 0.5}, "command")
+	assert.ErrorContains(t, err, "cpu.max")
+	assert.NoDirExists(t, filepath.Join(cgroupRoot, "command"))
+}
+
+func TestCmdStateInCgroup(t *testing.T) {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(cgroupRoot, &fs); err != nil || fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		t.Skip("requires cgroup v2")
+	}
+	current, err := currentCgroup()
+	require.NoError(t, err)
+	if unix.Access(filepath.Join(cgroupRoot, current), unix.W_OK) != nil {
+		t.Skip("requires a writable cgroup, e.g., delegated to the user")
+	}
+	parent := path.Join(current, "extcmd-test-"+strings.ToLower(t.Name()))
+	if err := os.Mkdir(filepath.Join(cgroupRoot, parent), 0o755); err != nil {
+		t.Skipf("cannot create cgroup: %v", err)
+	}
+	defer os.Remove(filepath.Join(cgroupRoot, parent))
+
+	opts := &CgroupOptions{Parent: filepath.Join(cgroupRoot, parent)}
+	// Controllers are only available if the current cgroup has no processes of its own, e.g., as the root cgroup.
+	if enableControllers(filepath.Join(cgroupRoot, current), []string{"cpu", "memory"}) == nil {
+		opts.CPUs, opts.MemoryMax = 0.5, 64<<20
+	}
+	// The background process outlives the command and is killed with the cgroup.
+	cs := NewCmdStateWithOptions(exec.Command("sh", "-c", "sleep 60 >/dev/null 2>&1 & grep ^0:: /proc/self/cgroup; ulimit -n"), Options{
+		Rlimits: []Rlimit{{Resource: unix.RLIMIT_NOFILE, Cur: 64, Max: 128}},
+		Cgroup:  opts,
+	})
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	cgroup := filepath.Join(cgroupRoot, parent, "extcmd-"+cs.Id)
+	cpuMax, cpuMaxErr := os.ReadFile(filepath.Join(cgroup, "cpu.max"))
+	require.NoError(t, cs.Wait())
+
+	if opts.CPUs > 0 {
+		require.NoError(t, cpuMaxErr)
+		assert.Equal(t, "50000 100000\n", string(cpuMax))
+	}
+	assert.Equal(t, []string{"0::" + path.Join(parent, "extcmd-"+cs.Id) + "\n", "64\n"}, cs.GetLines(true))
+	assert.NoDirExists(t, cgroup, "the cgroup is removed")
+}
+
+func TestCgroupOOMKilled(t *testing.T) {
+	cgroup := t.TempDir()
+	assert.False(t, cgroupOOMKilled(cgroup), "without memory controller")
+
+	events := filepath.Join(cgroup, "memory.events")
+	require.NoError(t, os.WriteFile(events, []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 0\noom_group_kill 0\n"), 0o644))
+	assert.False(t, cgroupOOMKilled(cgroup))
+
+	require.NoError(t, os.WriteFile(events, []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\noom_group_kill 0\n"), 0o644))
+	assert.True(t, cgroupOOMKilled(cgroup))
+}
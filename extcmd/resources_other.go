@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !linux
+
+package extcmd
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func (r *resources) start(cmd *exec.Cmd, _ string) error {
+	if r.configured() {
+		return errors.New("resource limits are only supported on Linux")
+	}
+	return cmd.Start()
+}
+
+func (r *resources) release() {}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Runner runs the commands started through CmdState.Start instead of starting them as processes, see SetRunner.
+type Runner interface {
+	// Start starts cmd. The output of the command must be written to cmd.Stdout and cmd.Stderr.
+	Start(cmd *exec.Cmd) (Process, error)
+}
+
+// Process is a command started by a Runner.
+type Process interface {
+	// Signal sends sig to the command and its child processes. It returns os.ErrProcessDone if the command exited.
+	Signal(sig os.Signal) error
+	// Wait blocks until the command exited and returns its ExitCode, Signal and resource usage. The remaining fields
+	// of ExitStatus are filled in by CmdState. The error is passed through by CmdState.Wait.
+	Wait() (ExitStatus, error)
+}
+
+var (
+	runnerMu sync.Mutex
+	runner   Runner
+)
+
+// SetRunner replaces how CmdState.Start runs commands, typically by a FakeRunner in tests. With nil, commands are
+// started as processes again. Resource limits and process groups configured through Options are left to the runner.
+// The returned function restores the previous runner. As the runner is global, tests replacing it must not run in
+// parallel.
+//
+// Only commands started through CmdState.Start are run by the runner. Commands started directly, i.e., through
+// cmd.Start() followed by `go cs.Wait()`, bypass it and are started as processes, even in tests.
+func SetRunner(r Runner) (restore func()) {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	previous := runner
+	runner = r
+	return func() {
+		runnerMu.Lock()
+		defer runnerMu.Unlock()
+		runner = previous
+	}
+}
+
+func currentRunner() Runner {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	return runner
+}
+
+// startWith starts the command through r.
+func (cs *CmdState) startWith(r Runner) error {
+	cs.startTime = time.Now()
+	p, err := r.Start(cs.Cmd)
+	if err != nil {
+		return err
+	}
+	cs.runnerProcess.Store(&p)
+	go func() { _ = cs.Wait() }()
+	return nil
+}
+
+// waitRunner waits for a command started through a Runner.
+func (cs *CmdState) waitRunner(p Process) {
+	status, err := p.Wait()
+	cs.waitErr = err
+	cs.exitCode.Store(int32(status.ExitCode))
+	cs.runnerStatus = status
+}
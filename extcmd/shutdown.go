@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/extension-kit/extsignals"
+)
+
+// StopAllSignalHandlerName is the name of the signal handler registered by AddStopAllSignalHandler.
+const StopAllSignalHandlerName = "StopCommands"
+
+// AddStopAllSignalHandler registers a signal handler that stops all registered commands through StopAll when the
+// extension receives SIGINT or SIGTERM. It runs at extsignals.OrderStopActions and returns after at most timeout, so
+// that the following shutdown steps are not delayed indefinitely.
+func AddStopAllSignalHandler(timeout time.Duration) {
+	extsignals.AddSignalHandler(stopAllSignalHandler(timeout))
+}
+
+func stopAllSignalHandler(timeout time.Duration) extsignals.SignalHandler {
+	return extsignals.SignalHandler{
+		Handler: func(signal os.Signal) {
+			if signal != syscall.SIGINT && signal != syscall.SIGTERM {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := StopAll(ctx); err != nil {
+				log.Warn().Err(err).Msg("failed to stop all commands")
+			}
+		},
+		Order: extsignals.OrderStopActions,
+		Name:  StopAllSignalHandlerName,
+	}
+}
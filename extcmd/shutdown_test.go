@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+//go:build !windows
+
+package extcmd
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCmdStates(t *testing.T) {
+	a := NewCmdState(exec.Command("true"))
+	defer RemoveCmdState(a.Id)
+	b := NewCmdState(exec.Command("true"))
+	defer RemoveCmdState(b.Id)
+
+	listed := ListCmdStates()
+	assert.Contains(t, listed, a)
+	assert.Contains(t, listed, b)
+
+	RemoveCmdState(a.Id)
+	assert.NotContains(t, ListCmdStates(), a)
+}
+
+func TestStopAllSignalHandlerStopsCommandsInParallel(t *testing.T) {
+	var started []*CmdState
+	for range 3 {
+		cs := NewCmdStateWithOptions(exec.Command("sh", "-c", `trap "" TERM; sleep 60`), Options{StopGracePeriod: 500 * time.Millisecond})
+		defer RemoveCmdState(cs.Id)
+		require.NoError(t, cs.Start())
+		started = append(started, cs)
+	}
+	notStarted := NewCmdState(exec.Command("true"))
+	defer RemoveCmdState(notStarted.Id)
+	time.Sleep(100 * time.Millisecond)
+
+	handler := stopAllSignalHandler(10 * time.Second)
+	handler.Handler(syscall.SIGUSR1)
+	for _, cs := range started {
+		assert.Equal(t, -1, cs.ExitCode())
+		select {
+		case <-cs.done:
+			assert.Fail(t, "command stopped on unrelated signal")
+		default:
+		}
+	}
+
+	start := time.Now()
+	handler.Handler(syscall.SIGTERM)
+	assert.Less(t, time.Since(start), 1500*time.Millisecond)
+	for _, cs := range started {
+		select {
+		case <-cs.done:
+		default:
+			assert.Fail(t, "command was not stopped")
+		}
+	}
+}
+
+func TestStopAllKillsWhenContextIsDone(t *testing.T) {
+	cs := NewCmdStateWithOptions(exec.Command("sh", "-c", `trap "" TERM; sleep 60`), Options{StopGracePeriod: time.Minute})
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+	err := StopAll(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Error(t, cs.Wait())
+}
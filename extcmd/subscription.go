@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"context"
+	"iter"
+)
+
+// Subscription reads the output of a command independently of other subscriptions and of GetLines, GetMessages and
+// GetEvents. Each subscription has a cursor of its own over the output retained by the CmdState, which is bounded by
+// Options.MaxOutputBytes and Options.MaxOutputLines. Lines dropped before a subscription read them are counted, see
+// Dropped.
+//
+// A Subscription must not be used concurrently.
+type Subscription struct {
+	cs      *CmdState
+	cursor  uint64
+	dropped int
+}
+
+// Subscribe returns a subscription to the output of the command, starting with the oldest retained line.
+func (cs *CmdState) Subscribe() *Subscription {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return &Subscription{cs: cs, cursor: cs.out.first}
+}
+
+// Read returns the complete lines written since the last call to Read or Follow without blocking.
+func (s *Subscription) Read() []Line {
+	s.cs.mu.Lock()
+	defer s.cs.mu.Unlock()
+	return s.read()
+}
+
+func (s *Subscription) read() []Line {
+	lines, missed, next := s.cs.out.since(s.cursor)
+	s.dropped += missed
+	s.cursor = next
+	return lines
+}
+
+// Dropped returns the number of lines that have been dropped before the subscription read them.
+func (s *Subscription) Dropped() int {
+	return s.dropped
+}
+
+// Follow returns an iterator over the lines of the command, waiting for new lines as they are written. The iteration
+// ends once the command exited and all of its output has been yielded, including a final line that has not been
+// terminated, or when ctx is done. Exiting is only observed for commands waited for through CmdState.Start or
+// CmdState.Wait.
+func (s *Subscription) Follow(ctx context.Context) iter.Seq[Line] {
+	return func(yield func(Line) bool) {
+		for {
+			s.cs.mu.Lock()
+			lines := s.read()
+			changes := s.cs.out.changes()
+			s.cs.mu.Unlock()
+
+			for _, l := range lines {
+				if !yield(l) {
+					return
+				}
+			}
+			if len(lines) > 0 {
+				continue
+			}
+
+			select {
+			case <-changes:
+			case <-s.cs.done:
+				// The output has been written completely once the command has been waited for.
+				s.cs.mu.Lock()
+				lines = append(s.read(), s.cs.out.partialLines()...)
+				s.cs.mu.Unlock()
+				for _, l := range lines {
+					if !yield(l) {
+						return
+					}
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
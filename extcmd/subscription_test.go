@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extcmd
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func texts(lines []Line) []string {
+	var result []string
+	for _, l := range lines {
+		result = append(result, l.Text)
+	}
+	return result
+}
+
+func TestSubscriptionsReadIndependently(t *testing.T) {
+	cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{})}
+	_, _ = cs.Write([]byte("a\n"))
+	first := cs.Subscribe()
+	_, _ = cs.Stderr().Write([]byte("b\npartial"))
+
+	assert.Equal(t, []string{"a\n", "b\n"}, cs.GetLines(false))
+	second := cs.Subscribe()
+
+	lines := first.Read()
+	assert.Equal(t, []string{"a\n", "b\n"}, texts(lines))
+	assert.Equal(t, Stderr, lines[1].Stream)
+	assert.Empty(t, first.Read())
+
+	_, _ = cs.Write([]byte("c\n"))
+	assert.Equal(t, []string{"c\n"}, texts(first.Read()))
+	assert.Equal(t, []string{"a\n", "b\n", "c\n"}, texts(second.Read()))
+	assert.Equal(t, []string{"c\n"}, cs.GetLines(false))
+}
+
+func TestSubscriptionCountsDroppedLines(t *testing.T) {
+	cs := &CmdState{mu: new(sync.Mutex), out: newOutputBuffer(Options{MaxOutputLines: 2})}
+	sub := cs.Subscribe()
+	_, _ = cs.Write([]byte("a\nb\nc\n"))
+
+	assert.Equal(t, []string{"b\n", "c\n"}, texts(sub.Read()))
+	assert.Equal(t, 1, sub.Dropped())
+
+	_, _ = cs.Write([]byte("d\n"))
+	assert.Equal(t, []string{"d\n"}, texts(sub.Read()))
+	assert.Equal(t, 1, sub.Dropped(), "lines read are not counted once dropped")
+}
+
+func TestSubscriptionFollowsUntilExit(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", "echo a; sleep 0.1; echo b >&2; sleep 0.1; printf c"))
+	defer RemoveCmdState(cs.Id)
+	sub := cs.Subscribe()
+	require.NoError(t, cs.Start())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	var lines []Line
+	for l := range sub.Follow(ctx) {
+		lines = append(lines, l)
+	}
+
+	require.NoError(t, ctx.Err())
+	assert.Equal(t, []string{"a\n", "b\n", "c"}, texts(lines))
+	assert.Equal(t, Stderr, lines[1].Stream)
+}
+
+func TestSubscriptionFollowEndsWithContext(t *testing.T) {
+	cs := NewCmdState(exec.Command("sh", "-c", "echo a; sleep 60"))
+	defer RemoveCmdState(cs.Id)
+	require.NoError(t, cs.Start())
+	defer cs.Stop(t.Context(), 0)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	var lines []string
+	for l := range cs.Subscribe().Follow(ctx) {
+		lines = append(lines, l.Text)
+		cancel()
+	}
+	assert.Equal(t, []string{"a\n"}, lines)
+}
@@ -12,11 +12,22 @@ import (
 // (to - typically also a struct or map[string]interface{}). This is helpful in a variety of cases,
 // e.g., to encode ActionKit's action state.
 //
-// This function leverages json.Marshal and json.Unmarshal internally whereas it previously leveraged
-// the mapstructure package. It turned out that using the json package is beneficial, as
-// many go internal (time.Time) and external packages (Kubernetes resource types) are compatible
-// with the json package, but not with mapstructure.
+// The result is the same as if from was encoded by json.Marshal and decoded into to by json.Unmarshal,
+// whereas it previously leveraged the mapstructure package. It turned out that using the json package
+// is beneficial, as many go internal (time.Time) and external packages (Kubernetes resource types) are
+// compatible with the json package, but not with mapstructure.
+//
+// To avoid the costs of the JSON round-trip, values of plain types, i.e., booleans, strings, integers, float64, and
+// maps, slices, pointers and structs of them, are converted directly into zero-valued targets. Everything else,
+// including values for which json.Unmarshal would report an error, is converted through the JSON round-trip.
 func Convert(from any, to any) error {
+	if convert(from, to) == nil {
+		return nil
+	}
+	return convertJson(from, to)
+}
+
+func convertJson(from any, to any) error {
 	bytes, err := json.Marshal(from)
 	if err != nil {
 		return err
@@ -4,9 +4,12 @@
 package extconversion
 
 import (
-	"github.com/stretchr/testify/require"
+	"encoding/json"
+	"math"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 type StructWithTime struct {
@@ -33,3 +36,427 @@ func TestConversionOfTime(t *testing.T) {
 	// Then
 	require.Equal(t, input.End, result.End)
 }
+
+type convertInner struct {
+	Name   string            `json:"name"`
+	Values []int             `json:"values,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ConvertEmbedded struct {
+	Embedded string `json:"embedded"`
+}
+
+type convertStruct struct {
+	ConvertEmbedded
+	*convertInner `json:"-"`
+	String        string          `json:"string"`
+	Int           int             `json:"int"`
+	Int64         int64           `json:"int64"`
+	Uint64        uint64          `json:"uint64"`
+	Float32       float32         `json:"float32"`
+	Float64       float64         `json:"float64"`
+	Bool          bool            `json:"bool,omitempty"`
+	Bytes         []byte          `json:"bytes"`
+	Pointer       *convertInner   `json:"pointer"`
+	Inner         convertInner    `json:"inner"`
+	Slice         []convertInner  `json:"slice"`
+	Array         [2]string       `json:"array"`
+	Map           map[string]any  `json:"map"`
+	Any           any             `json:"any"`
+	Time          time.Time       `json:"time"`
+	OptionalTime  *time.Time      `json:"optionalTime,omitempty"`
+	Zero          time.Time       `json:"zero,omitzero"`
+	Number        json.Number     `json:"number,omitempty"`
+	Raw           json.RawMessage `json:"raw,omitempty"`
+	Untagged      string
+	Ignored       string `json:"-"`
+	unexported    string
+}
+
+func newConvertStruct() convertStruct {
+	return convertStruct{
+		ConvertEmbedded: ConvertEmbedded{Embedded: "embedded"},
+		String:          "<string>",
+		Int:             -42,
+		Int64:           math.MaxInt64,
+		Uint64:          math.MaxUint64,
+		Float32:         0.1,
+		Float64:         1e21,
+		Bool:            true,
+		Bytes:           []byte("bytes"),
+		Pointer:         &convertInner{Name: "pointer", Values: []int{1, 2}},
+		Inner:           convertInner{Name: "inner", Labels: map[string]string{"a": "b"}},
+		Slice:           []convertInner{{Name: "first"}, {Name: "second", Values: []int{}}},
+		Array:           [2]string{"a", "b"},
+		Map:             map[string]any{"nested": map[string]any{"n": 1}, "list": []any{1, "two", nil}},
+		Any:             []int{1, 2, 3},
+		Time:            time.Date(2023, 2, 3, 4, 5, 6, 7, time.UTC),
+		Number:          "12.5",
+		Raw:             json.RawMessage(`{"raw":true}`),
+		Untagged:        "untagged",
+		Ignored:         "ignored",
+		unexported:      "unexported",
+	}
+}
+
+type textKey string
+
+func (k textKey) MarshalText() ([]byte, error) {
+	return []byte("key-" + string(k)), nil
+}
+
+type intKeyed map[int]string
+
+type plainMode string
+
+type plainStruct struct {
+	Name     string                   `json:"name"`
+	Mode     plainMode                `json:"mode,omitempty"`
+	Count    int                      `json:"count"`
+	Big      int64                    `json:"big"`
+	Unsigned uint64                   `json:"unsigned"`
+	Ratio    float64                  `json:"ratio"`
+	Enabled  bool                     `json:"enabled,omitempty"`
+	Inner    *convertInner            `json:"inner"`
+	Items    []convertInner           `json:"items"`
+	Labels   map[string]string        `json:"labels"`
+	Nested   map[string]*convertInner `json:"nested"`
+	Any      any                      `json:"any"`
+	Untagged []string
+	Ignored  string `json:"-"`
+	private  string
+}
+
+func newPlainStruct() plainStruct {
+	return plainStruct{
+		Name:     "<name>",
+		Count:    -42,
+		Big:      1 << 53,
+		Unsigned: 1 << 53,
+		Ratio:    0.1,
+		Inner:    &convertInner{Name: "inner", Values: []int{1, 2}},
+		Items:    []convertInner{{Name: "first"}, {Name: "second", Values: []int{}}},
+		Labels:   map[string]string{"a": "b"},
+		Nested:   map[string]*convertInner{"n": {Name: "n"}, "nil": nil},
+		Any:      map[string]any{"list": []any{1, "two", nil, []int{3}}, "n": uint8(4)},
+		Untagged: []string{},
+		Ignored:  "ignored",
+		private:  "private",
+	}
+}
+
+// assertConvertLikeJson verifies that Convert yields the same result and error as the JSON round-trip.
+func assertConvertLikeJson(t *testing.T, from any, newTarget func() any) {
+	t.Helper()
+	expected := newTarget()
+	expectedErr := convertJson(from, expected)
+	actual := newTarget()
+	actualErr := Convert(from, actual)
+
+	if expectedErr != nil {
+		require.EqualError(t, actualErr, expectedErr.Error())
+	} else {
+		require.NoError(t, actualErr)
+	}
+	require.Equal(t, expected, actual)
+}
+
+type cyclic struct {
+	Next *cyclic `json:"next"`
+}
+
+func newCyclicValue() *cyclic {
+	c := &cyclic{}
+	c.Next = c
+	return c
+}
+
+func targetOf[T any](initial T) func() any {
+	return func() any {
+		target := initial
+		return &target
+	}
+}
+
+func TestConvertLikeJson(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      any
+		newTarget func() any
+	}{
+		{
+			name:      "struct to map",
+			from:      newConvertStruct(),
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "struct pointer to any",
+			from:      new(newConvertStruct()),
+			newTarget: targetOf[any](nil),
+		},
+		{
+			name:      "struct to struct",
+			from:      newConvertStruct(),
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "struct into existing struct",
+			from:      convertStruct{Map: map[string]any{"b": 2}, Slice: []convertInner{{Name: "replaced"}}},
+			newTarget: func() any { return new(newConvertStruct()) },
+		},
+		{
+			name:      "map into existing map",
+			from:      map[string]any{"a": 1, "nested": map[string]any{"b": 2}},
+			newTarget: targetOf(map[string]any{"c": 3, "nested": map[string]any{"d": 4}}),
+		},
+		{
+			name: "map to struct",
+			from: map[string]any{
+				"string":   "string",
+				"int":      float64(-1),
+				"int64":    json.Number("9223372036854775807"),
+				"uint64":   json.Number("18446744073709551615"),
+				"float32":  0.1,
+				"float64":  json.Number("1e400"),
+				"bytes":    "Ynl0ZXM=",
+				"pointer":  map[string]any{"name": "pointer", "values": []any{1.0, 2.0}},
+				"slice":    []any{map[string]any{"name": "first"}, nil},
+				"array":    []any{"a", "b", "c"},
+				"map":      map[string]any{"nested": []any{true}},
+				"any":      map[string]any{"number": json.Number("1")},
+				"time":     "2023-02-03T04:05:06Z",
+				"number":   json.Number("1.5"),
+				"raw":      []any{"raw"},
+				"Untagged": "untagged",
+				"unknown":  map[string]any{"ignored": true},
+			},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "map with nulls into existing struct",
+			from:      map[string]any{"pointer": nil, "slice": nil, "map": nil, "any": nil, "string": nil, "time": nil},
+			newTarget: func() any { return new(newConvertStruct()) },
+		},
+		{
+			name:      "map with case-insensitive keys to struct",
+			from:      map[string]any{"NAME": "upper", "name": "exact", "Values": []any{1.0}},
+			newTarget: targetOf(convertInner{}),
+		},
+		{
+			name:      "map with colliding case-insensitive keys to struct",
+			from:      map[string]any{"NAME": "upper", "Name": "title"},
+			newTarget: targetOf(convertInner{}),
+		},
+		{
+			name:      "slice into existing slice",
+			from:      []convertInner{{Name: "a"}},
+			newTarget: targetOf([]convertInner{{Name: "x", Values: []int{1}}, {Name: "y"}}),
+		},
+		{
+			name:      "empty slice",
+			from:      []string{},
+			newTarget: targetOf([]string{"a"}),
+		},
+		{
+			name: "any holding a pointer",
+			from: map[string]any{"name": "name"},
+			newTarget: func() any {
+				var target any = &convertInner{Values: []int{1}}
+				return &target
+			},
+		},
+		{
+			name:      "big numbers to float32",
+			from:      []any{int64(16777217), uint64(math.MaxUint64), 1e300, json.Number("0.1")},
+			newTarget: targetOf([]float32{}),
+		},
+		{
+			name:      "numbers to integers",
+			from:      []any{1.0, -0.0, 1e20, math.MaxInt64, json.Number("-0")},
+			newTarget: targetOf([]int64{}),
+		},
+		{
+			name:      "negative zero to unsigned",
+			from:      []any{math.Copysign(0, -1)},
+			newTarget: targetOf([]uint{}),
+		},
+		{
+			name:      "fraction to integer",
+			from:      map[string]any{"int": 1.5, "string": "still set"},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "overflow",
+			from:      map[string]any{"values": []any{1e19}},
+			newTarget: targetOf(convertInner{}),
+		},
+		{
+			name:      "string to integer",
+			from:      map[string]any{"int": "1"},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "invalid time",
+			from:      map[string]any{"time": "yesterday"},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "invalid base64",
+			from:      map[string]any{"bytes": "!"},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "invalid UTF-8",
+			from:      map[string]any{"name\xff": "\xffname", "values": []any{}},
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "text marshaler keys",
+			from:      map[textKey]int{"a": 1},
+			newTarget: targetOf[map[string]int](nil),
+		},
+		{
+			name:      "integer keys",
+			from:      intKeyed{1: "one", -2: "two"},
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "unsupported value",
+			from:      map[string]any{"channel": make(chan int)},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "NaN",
+			from:      map[string]any{"float64": math.NaN()},
+			newTarget: targetOf(convertStruct{}),
+		},
+		{
+			name:      "plain struct to map",
+			from:      newPlainStruct(),
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "plain struct to plain struct",
+			from:      new(newPlainStruct()),
+			newTarget: targetOf(plainStruct{}),
+		},
+		{
+			name: "map to plain struct",
+			from: map[string]any{
+				"NAME":     "upper",
+				"mode":     "cpu",
+				"count":    float64(1 << 53),
+				"big":      uint64(math.MaxInt64),
+				"unsigned": -0.0,
+				"ratio":    int64(math.MaxInt64),
+				"inner":    nil,
+				"items":    []any{map[string]any{"name": "first"}, nil},
+				"labels":   map[string]any{},
+				"nested":   map[string]any{"n": nil},
+				"any":      []any{1, 2.5},
+				"untagged": []any{},
+				"Ignored":  "x",
+			},
+			newTarget: targetOf(plainStruct{}),
+		},
+		{
+			name:      "large integers",
+			from:      plainStruct{Big: math.MaxInt64, Unsigned: math.MaxUint64, Ratio: math.MaxInt64},
+			newTarget: targetOf(plainStruct{}),
+		},
+		{
+			name:      "large integers to map",
+			from:      plainStruct{Big: math.MaxInt64, Unsigned: math.MaxUint64},
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "big float to integer",
+			from:      map[string]any{"big": float64(1 << 60)},
+			newTarget: targetOf(plainStruct{}),
+		},
+		{
+			name:      "negative to unsigned",
+			from:      map[string]any{"unsigned": -1},
+			newTarget: targetOf(plainStruct{}),
+		},
+		{
+			name:      "cyclic value",
+			from:      newCyclicValue(),
+			newTarget: targetOf[map[string]any](nil),
+		},
+		{
+			name:      "non-pointer target",
+			from:      map[string]any{},
+			newTarget: func() any { return convertStruct{} },
+		},
+		{
+			name:      "nil",
+			from:      nil,
+			newTarget: func() any { return new(newConvertStruct()) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertConvertLikeJson(t, tt.from, tt.newTarget)
+		})
+	}
+}
+
+func TestConvertWithoutJsonRoundTrip(t *testing.T) {
+	from := newPlainStruct()
+
+	var state, expectedState map[string]any
+	require.NoError(t, convert(from, &state))
+	require.NoError(t, convertJson(from, &expectedState))
+	require.Equal(t, expectedState, state)
+
+	var result, expectedResult plainStruct
+	require.NoError(t, convert(state, &result))
+	require.NoError(t, convertJson(state, &expectedResult))
+	require.Equal(t, expectedResult, result)
+
+	var copied plainStruct
+	require.NoError(t, convert(from, &copied))
+	require.Equal(t, expectedResult, copied)
+}
+
+func TestConvertFallsBackWithoutModifyingTheTarget(t *testing.T) {
+	target := plainStruct{}
+	require.ErrorIs(t, convert(map[string]any{"name": "name", "count": "1"}, &target), errFallback)
+	require.Equal(t, plainStruct{}, target)
+
+	require.ErrorIs(t, convert(map[string]any{}, new(convertStruct{})), errFallback, "types with marshalers")
+	require.ErrorIs(t, convert(map[string]any{}, &plainStruct{Name: "set"}), errFallback, "targets that are not zero")
+}
+
+func TestConvertDoesNotShareValues(t *testing.T) {
+	state := map[string]any{"any": map[string]any{"a": []any{"b"}}}
+	var result convertStruct
+	require.NoError(t, Convert(state, &result))
+
+	state["any"].(map[string]any)["a"].([]any)[0] = "changed"
+	require.Equal(t, map[string]any{"a": []any{"b"}}, result.Any)
+}
+
+func benchmarkConvert(b *testing.B, convert func(from any, to any) error) {
+	from := newPlainStruct()
+	for b.Loop() {
+		var state map[string]any
+		if err := convert(from, &state); err != nil {
+			b.Fatal(err)
+		}
+		var result plainStruct
+		if err := convert(state, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvert(b *testing.B) {
+	benchmarkConvert(b, Convert)
+}
+
+func BenchmarkConvertJson(b *testing.B) {
+	benchmarkConvert(b, convertJson)
+}
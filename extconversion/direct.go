@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extconversion
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"unicode/utf8"
+)
+
+// errFallback signals that the direct conversion cannot guarantee the result of the JSON round-trip. The conversion is
+// then done through encoding/json, which also produces the appropriate error, if any.
+var errFallback = errors.New("conversion requires a JSON round-trip")
+
+// maxDepth limits the nesting converted directly, so that cyclic values are left to encoding/json, which reports them.
+const maxDepth = 100
+
+// maxExactInt is the largest integer up to which all integers can be represented by a float64.
+const maxExactInt = 1 << 53
+
+// convert converts from into the zero value to points to, like the JSON round-trip would. The result is built in a new
+// value and only stored if the conversion succeeds, so that the target is left untouched for the JSON round-trip
+// otherwise. Targets that are not zero are left to encoding/json, as it decodes into the existing values.
+func convert(from any, to any) error {
+	target := reflect.ValueOf(to)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return errFallback
+	}
+	t := target.Type().Elem()
+	if !isPlain(t) || !target.Elem().IsZero() {
+		return errFallback
+	}
+
+	result := reflect.New(t).Elem()
+	if err := decode(reflect.ValueOf(from), result, 0); err != nil {
+		return err
+	}
+	target.Elem().Set(result)
+	return nil
+}
+
+// resolve returns the value encoding/json encodes for v, following pointers and interfaces. The result is invalid if
+// it is encoded as null.
+func resolve(v reflect.Value) (reflect.Value, error) {
+	for v.IsValid() {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				return reflect.Value{}, nil
+			}
+			v = v.Elem()
+			continue
+		case reflect.Map, reflect.Slice:
+			if v.IsNil() {
+				return reflect.Value{}, nil
+			}
+		}
+		if !isPlain(v.Type()) {
+			return reflect.Value{}, errFallback
+		}
+		break
+	}
+	return v, nil
+}
+
+// decode stores the value src into the zero value dst, like json.Unmarshal stores the JSON representation of src.
+func decode(src reflect.Value, dst reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return errFallback
+	}
+	src, err := resolve(src)
+	if err != nil || !src.IsValid() {
+		// null leaves the zero value as it is.
+		return err
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		value, err := generic(src, depth)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(value))
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := decode(src, elem.Elem(), depth+1); err != nil {
+			return err
+		}
+		dst.Set(elem)
+	case reflect.Bool:
+		if src.Kind() != reflect.Bool {
+			return errFallback
+		}
+		dst.SetBool(src.Bool())
+	case reflect.String:
+		if src.Kind() != reflect.String || !utf8.ValidString(src.String()) {
+			return errFallback
+		}
+		dst.SetString(src.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt(src)
+		if !ok || dst.OverflowInt(i) {
+			return errFallback
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, ok := toUint(src)
+		if !ok || dst.OverflowUint(u) {
+			return errFallback
+		}
+		dst.SetUint(u)
+	case reflect.Float64:
+		f, ok := toFloat(src)
+		if !ok {
+			return errFallback
+		}
+		dst.SetFloat(f)
+	case reflect.Map:
+		result := reflect.MakeMap(dst.Type())
+		err := members(src, func(key string, value reflect.Value) error {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decode(value, elem, depth+1); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		dst.Set(result)
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice {
+			return errFallback
+		}
+		result := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := range src.Len() {
+			if err := decode(src.Index(i), result.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		dst.Set(result)
+	case reflect.Struct:
+		fields, _ := structFields(dst.Type())
+		stored := make([]bool, dst.NumField())
+		return members(src, func(key string, value reflect.Value) error {
+			f, ok := field(fields, key)
+			if !ok {
+				return nil
+			}
+			// encoding/json sorts the keys of maps, which decides which of several case-insensitive matches wins.
+			if stored[f.index] {
+				return errFallback
+			}
+			stored[f.index] = true
+			return decode(value, dst.Field(f.index), depth+1)
+		})
+	default:
+		return errFallback
+	}
+	return nil
+}
+
+// generic returns src as json.Unmarshal into an any would produce it from the JSON representation of src.
+func generic(src reflect.Value, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errFallback
+	}
+	src, err := resolve(src)
+	if err != nil || !src.IsValid() {
+		return nil, err
+	}
+
+	switch src.Kind() {
+	case reflect.Bool:
+		return src.Bool(), nil
+	case reflect.String:
+		if !utf8.ValidString(src.String()) {
+			return nil, errFallback
+		}
+		return src.String(), nil
+	case reflect.Map, reflect.Struct:
+		result := make(map[string]any)
+		err := members(src, func(key string, value reflect.Value) error {
+			member, err := generic(value, depth+1)
+			result[key] = member
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	case reflect.Slice:
+		result := make([]any, src.Len())
+		for i := range src.Len() {
+			if result[i], err = generic(src.Index(i), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	default:
+		f, ok := toFloat(src)
+		if !ok {
+			return nil, errFallback
+		}
+		return f, nil
+	}
+}
+
+// members calls fn for the members of the JSON object src is encoded as.
+func members(src reflect.Value, fn func(key string, value reflect.Value) error) error {
+	switch src.Kind() {
+	case reflect.Map:
+		for iter := src.MapRange(); iter.Next(); {
+			key := iter.Key().String()
+			if !utf8.ValidString(key) {
+				return errFallback
+			}
+			if err := fn(key, iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields, _ := structFields(src.Type())
+		for _, f := range fields {
+			value := src.Field(f.index)
+			if f.omitEmpty && isEmpty(value) {
+				continue
+			}
+			if err := fn(f.name, value); err != nil {
+				return err
+			}
+		}
+	default:
+		return errFallback
+	}
+	return nil
+}
+
+// isEmpty reports whether a field with the omitempty option is omitted.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	default:
+		return v.IsZero()
+	}
+}
+
+// toInt returns the integer the JSON number src is decoded as. Larger floats are encoded with fewer significant
+// digits, e.g. 1<<60 as 1152921504606847000, so only floats up to maxExactInt are converted.
+func toInt(src reflect.Value) (int64, bool) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return src.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(src.Uint()), src.Uint() <= math.MaxInt64
+	case reflect.Float64:
+		f := src.Float()
+		return int64(f), f == math.Trunc(f) && math.Abs(f) <= maxExactInt
+	default:
+		return 0, false
+	}
+}
+
+func toUint(src reflect.Value) (uint64, bool) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(src.Int()), src.Int() >= 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return src.Uint(), true
+	case reflect.Float64:
+		// -0 is encoded as "-0", which is rejected for unsigned integers.
+		f := src.Float()
+		return uint64(f), f == math.Trunc(f) && !math.Signbit(f) && f <= maxExactInt
+	default:
+		return 0, false
+	}
+}
+
+// toFloat returns the float64 the JSON number src is decoded as. Integers are rounded to the nearest float64, just
+// like their decimal representation is when parsed.
+func toFloat(src reflect.Value) (float64, bool) {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(src.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(src.Uint()), true
+	case reflect.Float64:
+		f := src.Float()
+		return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extconversion
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	marshalerType       = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	unmarshalerType     = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	numberType          = reflect.TypeFor[json.Number]()
+)
+
+// plainField is a field of a plain struct, see isPlain.
+type plainField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+var (
+	plainCache  sync.Map // map[reflect.Type]bool
+	fieldsCache sync.Map // map[reflect.Type][]plainField
+)
+
+// isPlain reports whether values of type t are converted directly. These are booleans, strings, integers, float64,
+// empty interfaces, and pointers, slices, maps with string keys and structs of plain types. Types with custom
+// (text) marshalers, byte slices, float32, arrays, json.Number and structs with embedded fields, tag options other
+// than omitempty or field names that only differ in case are left to encoding/json.
+func isPlain(t reflect.Type) bool {
+	if plain, ok := plainCache.Load(t); ok {
+		return plain.(bool)
+	}
+	plain := typeIsPlain(t, map[reflect.Type]bool{})
+	plainCache.Store(t, plain)
+	return plain
+}
+
+func typeIsPlain(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		// The type is checked further up the tree already.
+		return true
+	}
+	visited[t] = true
+
+	if t == numberType || hasMarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Pointer:
+		return typeIsPlain(t.Elem(), visited)
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8 && typeIsPlain(t.Elem(), visited)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && !hasMarshaler(t.Key()) && typeIsPlain(t.Elem(), visited)
+	case reflect.Struct:
+		fields, ok := structFields(t)
+		if !ok {
+			return false
+		}
+		for _, f := range fields {
+			if !typeIsPlain(t.Field(f.index).Type, visited) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func hasMarshaler(t reflect.Type) bool {
+	for _, u := range []reflect.Type{t, reflect.PointerTo(t)} {
+		if u.Implements(marshalerType) || u.Implements(textMarshalerType) ||
+			u.Implements(unmarshalerType) || u.Implements(textUnmarshalerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// structFields returns the fields encoding/json serializes for the struct type t, or false if t is not a plain struct.
+func structFields(t reflect.Type) ([]plainField, bool) {
+	if fields, ok := fieldsCache.Load(t); ok {
+		return fields.([]plainField), fields.([]plainField) != nil
+	}
+	fields, ok := newStructFields(t)
+	if !ok {
+		fields = nil
+	} else if fields == nil {
+		fields = []plainField{}
+	}
+	fieldsCache.Store(t, fields)
+	return fields, ok
+}
+
+func newStructFields(t reflect.Type) ([]plainField, bool) {
+	var fields []plainField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if sf.Anonymous {
+			return nil, false
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts != "" && opts != "omitempty" {
+			return nil, false
+		}
+		if name == "" {
+			name = sf.Name
+		} else if !isSimpleName(name) {
+			return nil, false
+		}
+		for _, other := range fields {
+			if strings.EqualFold(other.name, name) {
+				return nil, false
+			}
+		}
+		fields = append(fields, plainField{index: i, name: name, omitEmpty: opts == "omitempty"})
+	}
+	return fields, true
+}
+
+// isSimpleName reports whether name only consists of letters, digits, '_', '-' and '.', which encoding/json accepts as
+// field name in any case.
+func isSimpleName(name string) bool {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// field returns the field the member key is stored into. Like encoding/json, keys are matched case-insensitively.
+func field(fields []plainField, key string) (plainField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return plainField{}, false
+}
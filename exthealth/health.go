@@ -70,8 +70,8 @@ func StartProbes(port int) {
 		Name:  "SetReadinessToFalse",
 	})
 	if exthttp.IsUnixSocketEnabled() {
-		addLivenessProbe(http.Handle)
-		addReadinessProbe(http.Handle)
+		addLivenessProbe(exthttp.Handle)
+		addReadinessProbe(exthttp.Handle)
 		return
 	}
 
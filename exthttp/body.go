@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/steadybit/extension-kit"
+)
+
+var (
+	// MaxRequestBodyBytes limits the size of request bodies read for a Handler, unless configured otherwise for the
+	// route through WithMaxBodyBytes. Larger bodies are rejected with 413.
+	MaxRequestBodyBytes int64 = 10 << 20
+	// MaxLoggedBodyBytes limits how much of a request body is logged at debug level.
+	MaxLoggedBodyBytes = 4 << 10
+)
+
+type bodyConfig struct {
+	// maxBytes limits the size of the body, 0 means the default limit and a negative value no limit.
+	maxBytes  int64
+	streaming bool
+}
+
+// WithMaxBodyBytes limits the request bodies of the route to n bytes instead of MaxRequestBodyBytes. With n <= 0, the
+// bodies are not limited. The limit also applies to streaming and multipart bodies, which are not limited by default.
+func WithMaxBodyBytes(n int64) RouteOption {
+	if n <= 0 {
+		n = -1
+	}
+	return func(route *routeConfig) {
+		route.body.maxBytes = n
+	}
+}
+
+// WithStreamingBody leaves reading the request body to the Handler of the route, which is passed a nil body then and
+// reads it from the request. Like multipart bodies, streaming bodies are neither buffered nor logged.
+func WithStreamingBody() RouteOption {
+	return func(route *routeConfig) {
+		route.body.streaming = true
+	}
+}
+
+type bodyConfigKey struct{}
+
+// withBodyConfig passes the body configuration of a route on to readBody.
+func withBodyConfig(next http.Handler, cfg bodyConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bodyConfigKey{}, cfg)))
+	})
+}
+
+// readBody reads the request body for a Handler. Streaming and multipart bodies are left to the handler, nil is returned
+// for them. If the body cannot be read or is too large, the error is responded with and false is returned.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	cfg, _ := r.Context().Value(bodyConfigKey{}).(bodyConfig)
+	buffered := !cfg.streaming && !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+
+	limit := cfg.maxBytes
+	if limit == 0 && buffered {
+		limit = MaxRequestBodyBytes
+	}
+	if limit > 0 {
+		if r.ContentLength > limit {
+			writeBodyTooLarge(w, r, limit)
+			return nil, false
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	if !buffered {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			writeBodyTooLarge(w, r, limit)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	err := fmt.Errorf("the request body exceeds the limit of %d bytes", limit)
+	WriteErrorForRequest(w, r, extension_kit.ToError("Request body too large", err).WithStatus(http.StatusRequestEntityTooLarge))
+}
+
+// loggedBody returns the part of body that is logged, see MaxLoggedBodyBytes.
+func loggedBody(body []byte) (logged []byte, truncated bool) {
+	if MaxLoggedBodyBytes >= 0 && len(body) > MaxLoggedBodyBytes {
+		return body[:MaxLoggedBodyBytes], true
+	}
+	return body, false
+}
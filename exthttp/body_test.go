@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/extension-kit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBodyLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []RouteOption
+		body          string
+		unknownLength bool
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:       "within the default limit",
+			body:       "0123456789",
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+		},
+		{
+			name:       "exceeds the route limit",
+			opts:       []RouteOption{WithMaxBodyBytes(5)},
+			body:       "0123456789",
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "exceeds the route limit without content length",
+			opts:          []RouteOption{WithMaxBodyBytes(5)},
+			body:          "0123456789",
+			unknownLength: true,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "request logging removed",
+			opts:          []RouteOption{WithMaxBodyBytes(5), WithoutMiddleware(MiddlewareLogRequest)},
+			body:          "0123456789",
+			unknownLength: true,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "unlimited",
+			opts:       []RouteOption{WithMaxBodyBytes(0)},
+			body:       strings.Repeat("x", 100),
+			wantStatus: http.StatusOK,
+			wantBody:   strings.Repeat("x", 100),
+		},
+		{
+			name:       "streaming",
+			opts:       []RouteOption{WithStreamingBody()},
+			body:       "0123456789",
+			wantStatus: http.StatusOK,
+			wantBody:   "streamed 0123456789",
+		},
+		{
+			name:          "streaming exceeds the route limit",
+			opts:          []RouteOption{WithStreamingBody(), WithMaxBodyBytes(5)},
+			body:          "0123456789",
+			unknownLength: true,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			s.RegisterHttpHandler("POST /", func(w http.ResponseWriter, r *http.Request, body []byte) {
+				if body == nil {
+					streamed, err := io.ReadAll(r.Body)
+					if err != nil {
+						writeBodyTooLarge(w, r, 5)
+						return
+					}
+					body = append([]byte("streamed "), streamed...)
+				}
+				_, _ = w.Write(body)
+			}, tt.opts...)
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.unknownLength {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				var extErr extension_kit.ExtensionError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &extErr))
+				assert.Equal(t, "Request body too large", extErr.Title)
+				return
+			}
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestDefaultRequestBodyLimit(t *testing.T) {
+	defer func(previous int64) { MaxRequestBodyBytes = previous }(MaxRequestBodyBytes)
+	MaxRequestBodyBytes = 5
+	s := NewServer()
+	s.RegisterHttpHandler("POST /", func(w http.ResponseWriter, r *http.Request, body []byte) {
+		if body == nil {
+			body, _ = io.ReadAll(r.Body)
+		}
+		_, _ = w.Write(body)
+	})
+
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("multipart/form-data; boundary=x", "0123456789")
+	assert.Equal(t, http.StatusOK, w.Code, "multipart bodies are not limited by default")
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("application/json", "0123456789").Code)
+	MaxRequestBodyBytes = 20
+	assert.Equal(t, http.StatusOK, post("application/json", "0123456789").Code, "the limit is read per request")
+}
+
+func TestRequestBodyLoggingIsTruncated(t *testing.T) {
+	var logs bytes.Buffer
+	oldLogger, oldMax := log.Logger, MaxLoggedBodyBytes
+	defer func() { log.Logger, MaxLoggedBodyBytes = oldLogger, oldMax }()
+	log.Logger = zerolog.New(&logs).Level(zerolog.DebugLevel)
+	MaxLoggedBodyBytes = 4
+
+	var received []byte
+	h := LogRequestWithDefaultLogLevel(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		received = body
+	}, zerolog.InfoLevel)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+
+	assert.Equal(t, "0123456789", string(received))
+	var entry map[string]any
+	line, _, _ := strings.Cut(logs.String(), "\n")
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, "Request received", entry["message"])
+	assert.Equal(t, "0123", entry["body"])
+	assert.Equal(t, true, entry["body_truncated"])
+	assert.EqualValues(t, 10, entry["req_size"])
+}
@@ -9,14 +9,13 @@ package exthttp
 import (
 	"encoding/json"
 	"fmt"
-	"io"
+	"mime"
 	"net/http"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/klauspost/compress/gzhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
 	"github.com/rs/zerolog/log"
@@ -25,14 +24,25 @@ import (
 
 type Handler func(w http.ResponseWriter, r *http.Request, body []byte)
 
-// RegisterHttpHandler registers a handler for the given path. Also adds panic recovery, gzip compression and request logging around the handler.
-func RegisterHttpHandler(path string, handler Handler) {
-	RegisterHttpHandlerWithLogLevel(path, handler, zerolog.InfoLevel)
+// RegisterHttpHandler registers a handler for the given path on the DefaultServer. Also adds panic recovery, gzip compression and request logging around the handler.
+// The middleware can be adjusted through opts, see Server.RegisterHttpHandler.
+func RegisterHttpHandler(path string, handler Handler, opts ...RouteOption) {
+	DefaultServer.RegisterHttpHandler(path, handler, opts...)
 }
 
-// RegisterHttpHandlerWithLogLevel registers a handler for the given path. Also adds panic recovery, gzip compression and request logging with a given log level around the handler.
+// RegisterHttpHandlerWithLogLevel registers a handler for the given path on the DefaultServer. Also adds panic recovery, gzip compression and request logging with a given log level around the handler.
 func RegisterHttpHandlerWithLogLevel(path string, handler Handler, defaultLevel zerolog.Level) {
-	http.Handle(path, PanicRecovery(gzhttp.GzipHandler(RequestTimeoutHeaderAware(LogRequestWithDefaultLogLevel(handler, defaultLevel)))))
+	DefaultServer.RegisterHttpHandlerWithLogLevel(path, handler, defaultLevel)
+}
+
+// Handle registers an http.Handler for the given pattern on the DefaultServer, without any middleware.
+func Handle(pattern string, handler http.Handler) {
+	DefaultServer.Handle(pattern, handler)
+}
+
+// Use appends middleware to the chain of the DefaultServer, see Server.Use.
+func Use(middleware ...Middleware) {
+	DefaultServer.Use(middleware...)
 }
 
 // GetterAsHandler turns a getter function into a handler function. Typically used in combination with the RegisterHttpHandler function.
@@ -49,7 +59,7 @@ func PanicRecovery(next http.Handler) http.Handler {
 				log.Error().Msgf("Panic: %v\n %s", err, string(debug.Stack()))
 				response := extension_kit.ToError("Internal Server Error", nil)
 				response.Detail = new(fmt.Sprintf("Panic: %v", err))
-				WriteError(w, response)
+				WriteErrorForRequest(w, r, response)
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -74,29 +84,36 @@ func RequestTimeoutHeaderAware(next http.Handler) http.HandlerFunc {
 	}
 }
 
+// LogRequestWithDefaultLogLevel reads the request body for the handler and logs the request, GET requests at debug and
+// all others at defaultLevel. Bodies larger than MaxRequestBodyBytes are rejected with 413, the body is logged at debug
+// level up to MaxLoggedBodyBytes, with secrets masked through RequestLogRedactor and SensitiveHeaders.
 func LogRequestWithDefaultLogLevel(next Handler, defaultLevel zerolog.Level) http.Handler {
+	return logRequest(asHttpHandler(next), defaultLevel)
+}
+
+func logRequest(next http.Handler, defaultLevel zerolog.Level) http.Handler {
 	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		level := defaultLevel
 		if r.Method == "GET" {
 			level = zerolog.DebugLevel
 		}
 
-		var reqBody []byte = nil
-		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
-			if bytes, err := io.ReadAll(r.Body); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
-			} else {
-				reqBody = bytes
-			}
+		reqBody, ok := readBody(w, r)
+		if !ok {
+			return
 		}
 
-		hlog.FromRequest(r).Debug().
-			Str("method", r.Method).
-			Stringer("url", r.URL).
-			Int("req_size", len(reqBody)).
-			Bytes("body", reqBody).
-			Msg("Request received")
+		if event := hlog.FromRequest(r).Debug(); event.Enabled() {
+			logged, truncated := loggedBody(RequestLogRedactor.RedactJson(reqBody))
+			event.
+				Str("method", r.Method).
+				Stringer("url", r.URL).
+				Interface("headers", loggedHeaders(r.Header)).
+				Int("req_size", len(reqBody)).
+				Bytes("body", logged).
+				Bool("body_truncated", truncated).
+				Msg("Request received")
+		}
 
 		hlog.AccessHandler(func(r *http.Request, status, size int, duration time.Duration) {
 			hlog.FromRequest(r).WithLevel(level).
@@ -108,7 +125,7 @@ func LogRequestWithDefaultLogLevel(next Handler, defaultLevel zerolog.Level) htt
 				Int("status", status).
 				Msg("")
 		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			next(w, r, reqBody)
+			next.ServeHTTP(w, withRequestBody(r, reqBody))
 		})).ServeHTTP(w, r)
 	})
 
@@ -117,12 +134,38 @@ func LogRequestWithDefaultLogLevel(next Handler, defaultLevel zerolog.Level) htt
 	return handler
 }
 
-// WriteError writes the error as the HTTP response body with status code 500.
+// WriteError writes the error as the HTTP response body. The status code is taken from the error (see
+// extension_kit.ExtensionError.StatusCode) and defaults to 500. Client errors (4xx) are logged as warnings,
+// everything else as errors. Handlers registered through RegisterHttpHandler respond with the content type
+// application/problem+json (RFC 9457) if the request accepts it, see WriteErrorForRequest.
 func WriteError(w http.ResponseWriter, err extension_kit.ExtensionError) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	contentType := "application/json"
+	if _, ok := w.(problemJsonWriter); ok {
+		contentType = "application/problem+json"
+	}
+	writeError(w, err, contentType)
+}
+
+// WriteErrorForRequest behaves like WriteError, but responds with the content type application/problem+json
+// (RFC 9457) if the request accepts it.
+func WriteErrorForRequest(w http.ResponseWriter, r *http.Request, err extension_kit.ExtensionError) {
+	contentType := "application/json"
+	if acceptsProblemJson(r) {
+		contentType = "application/problem+json"
+	}
+	writeError(w, err, contentType)
+}
+
+func writeError(w http.ResponseWriter, err extension_kit.ExtensionError, contentType string) {
+	status := err.StatusCode()
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
 
 	logEvent := log.Error()
+	if status < 500 {
+		logEvent = log.Warn()
+	}
+	logEvent.Int("status", status)
 	if err.Detail != nil {
 		logEvent.Str("details", *err.Detail)
 	}
@@ -134,6 +177,39 @@ func WriteError(w http.ResponseWriter, err extension_kit.ExtensionError) {
 	}
 }
 
+// problemJsonWriter marks the response to a request accepting application/problem+json, so that WriteError can
+// negotiate the content type without the request.
+type problemJsonWriter struct {
+	http.ResponseWriter
+}
+
+func (w problemJsonWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w problemJsonWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func acceptsProblemJson(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for mediaRange := range strings.SplitSeq(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil || mediaType != "application/problem+json" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
 // WriteBody writes the given value as the HTTP response body as JSON with status code 200.
 func WriteBody(w http.ResponseWriter, response any) {
 	w.Header().Set("Content-Type", "application/json")
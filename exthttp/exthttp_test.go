@@ -16,6 +16,7 @@ import (
 
 	"github.com/klauspost/compress/gzhttp"
 	"github.com/rs/zerolog"
+	"github.com/steadybit/extension-kit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -272,3 +273,97 @@ func TestGzipHandler(t *testing.T) {
 		assert.Equal(t, largeBody, rr.Body.String())
 	})
 }
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          extension_kit.ExtensionError
+		wantedStatus int
+		wantedBody   string
+	}{
+		{
+			name:         "should default to 500",
+			err:          extension_kit.ToError("boom", nil),
+			wantedStatus: 500,
+			wantedBody:   "{\"title\":\"boom\"}\n",
+		},
+		{
+			name:         "should use status of error",
+			err:          extension_kit.ToNotFoundError("target not found", errors.New("no such container")),
+			wantedStatus: 404,
+			wantedBody:   "{\"detail\":\"no such container\",\"title\":\"target not found\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			WriteError(rr, tt.err)
+
+			assert.Equal(t, tt.wantedStatus, rr.Code)
+			assert.Equal(t, tt.wantedBody, rr.Body.String())
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestWriteErrorForRequest(t *testing.T) {
+	tests := []struct {
+		name              string
+		accept            string
+		wantedContentType string
+	}{
+		{
+			name:              "should use application/json by default",
+			accept:            "",
+			wantedContentType: "application/json",
+		},
+		{
+			name:              "should use application/problem+json if accepted",
+			accept:            "application/json, application/problem+json;q=0.9",
+			wantedContentType: "application/problem+json",
+		},
+		{
+			name:              "should not use application/problem+json if refused",
+			accept:            "application/problem+json;q=0",
+			wantedContentType: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			WriteErrorForRequest(rr, req, extension_kit.ToBadRequestError("invalid", nil).WithTraceId("abc"))
+
+			assert.Equal(t, 400, rr.Code)
+			assert.Equal(t, tt.wantedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, "{\"title\":\"invalid\",\"traceId\":\"abc\"}\n", rr.Body.String())
+		})
+	}
+}
+
+func TestWriteErrorNegotiatesForRegisteredHandlers(t *testing.T) {
+	s := NewServer()
+	s.RegisterHttpHandler("POST /", func(w http.ResponseWriter, r *http.Request, body []byte) {
+		WriteError(w, extension_kit.ToBadRequestError("invalid", nil))
+	})
+
+	for accept, wantedContentType := range map[string]string{
+		"":                         "application/json",
+		"application/problem+json": "application/problem+json",
+	} {
+		req := httptest.NewRequest("POST", "/", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		assert.Equal(t, 400, rr.Code)
+		assert.Equal(t, wantedContentType, rr.Header().Get("Content-Type"), "accept %q", accept)
+	}
+}
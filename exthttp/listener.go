@@ -16,11 +16,10 @@ import (
 	stdLog "log"
 	"net"
 	"net/http"
-	_ "net/http/pprof" // NOSONAR go:S4507 (pprof handlers are disabled by default; see hidePprofHandlers)
+	_ "net/http/pprof" // NOSONAR go:S4507 (pprof handlers are disabled by default; see Server.hidePprofHandlers)
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
@@ -37,11 +36,6 @@ type ListenSpecification struct {
 	EnablePprof   bool     `json:"enablePprof" split_words:"true" required:"false"`
 }
 
-var (
-	wrapper   *httpServerWrapper
-	serveCond = sync.NewCond(&sync.Mutex{})
-)
-
 func (spec *ListenSpecification) parseConfigurationFromEnvironment() {
 	err := envconfig.Process("steadybit_extension", spec)
 	if err != nil {
@@ -80,10 +74,18 @@ type ListenOpts struct {
 type httpServerWrapper struct {
 	serve  func() error
 	server *http.Server
+	addr   net.Addr
 }
 
+// Listen starts the DefaultServer and blocks until it is stopped, see Server.Listen.
 func Listen(opts ListenOpts) {
-	err := listen(opts)
+	DefaultServer.Listen(opts)
+}
+
+// Listen starts the server on the port, TLS configuration or unix socket configured through the environment and
+// blocks until it is stopped. It exits the extension if the server cannot be started.
+func (s *Server) Listen(opts ListenOpts) {
+	err := s.listen(opts)
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal().Err(err).Msgf("Failed to start extension server")
 	}
@@ -95,32 +97,22 @@ func IsUnixSocketEnabled() bool {
 	return spec.UnixSocket != ""
 }
 
-func hidePprofHandlers(spec ListenSpecification) {
+func (s *Server) hidePprofHandlers(spec ListenSpecification) {
+	s.pprofEnabled.Store(spec.EnablePprof)
 	if spec.EnablePprof {
 		log.Info().Msg("pprof handlers enabled")
 		return
 	}
-
 	log.Debug().Msg("disabling pprof handlers")
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/debug/pprof", func(writer http.ResponseWriter, request *http.Request) {
-		writer.WriteHeader(http.StatusNotFound)
-	})
-	mux.HandleFunc("/debug/pprof/", func(writer http.ResponseWriter, request *http.Request) {
-		writer.WriteHeader(http.StatusNotFound)
-	})
-	mux.Handle("/", http.DefaultServeMux)
-	http.DefaultServeMux = mux
 }
 
-func listen(opts ListenOpts) error {
+func (s *Server) listen(opts ListenOpts) error {
 
 	success := false
-	serveCond.L.Lock()
+	s.serveCond.L.Lock()
 	defer func() {
 		if !success {
-			serveCond.L.Unlock()
+			s.serveCond.L.Unlock()
 		}
 	}()
 
@@ -130,24 +122,26 @@ func listen(opts ListenOpts) error {
 		return fmt.Errorf("failed to validate listen specification: %w", err)
 
 	}
-	hidePprofHandlers(spec)
+	s.hidePprofHandlers(spec)
 
 	port := opts.Port
 	if spec.Port != 0 {
 		port = spec.Port
 	}
 
+	var wrapper *httpServerWrapper
 	var err error
 	if spec.UnixSocket != "" {
-		wrapper, err = prepareUnixSocketServer(spec.UnixSocket)
+		wrapper, err = prepareUnixSocketServer(spec.UnixSocket, s)
 	} else if spec.isTlsEnabled() {
-		wrapper, err = prepareHttpsServer(port, spec)
+		wrapper, err = prepareHttpsServer(port, spec, s)
 	} else {
-		wrapper, err = prepareHttpServer(port)
+		wrapper, err = prepareHttpServer(port, s)
 	}
 	if err != nil {
 		return err
 	}
+	s.wrapper = wrapper
 
 	extsignals.AddSignalHandler(extsignals.SignalHandler{
 		Handler: func(signal os.Signal) {
@@ -155,21 +149,22 @@ func listen(opts ListenOpts) error {
 			defer func() {
 				cancel()
 			}()
-			if wrapper == nil || wrapper.server == nil {
+			server := s.takeServer()
+			if server == nil {
 				return
 			}
 			log.Info().Msg("Stopping Extension HTTP Server")
-			if err := wrapper.server.Shutdown(ctx); err != nil {
+			if err := server.Shutdown(ctx); err != nil {
 				log.Warn().Msgf("Extension HTTP Server Shutdown Failed: %+v", err)
 			}
-			wrapper = nil
 		},
 		Order: extsignals.OrderStopExtensionHttp,
-		Name:  "StopExtensionHTTP",
+		Name:  s.shutdownHandlerName,
 	})
 
-	serveCond.Broadcast()
-	serveCond.L.Unlock()
+	s.serving = true
+	s.serveCond.Broadcast()
+	s.serveCond.L.Unlock()
 	success = true
 	if err = wrapper.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
@@ -177,20 +172,47 @@ func listen(opts ListenOpts) error {
 	return nil
 }
 
+// WaitForServe blocks until the DefaultServer is started, see Server.WaitForServe.
 func WaitForServe() {
-	serveCond.L.Lock()
-	defer serveCond.L.Unlock()
-	serveCond.Wait()
+	DefaultServer.WaitForServe()
 }
 
+// WaitForServe blocks until the server is started through Listen.
+func (s *Server) WaitForServe() {
+	s.serveCond.L.Lock()
+	defer s.serveCond.L.Unlock()
+	for !s.serving {
+		s.serveCond.Wait()
+	}
+}
+
+// StopListen stops the DefaultServer, see Server.StopListen.
 func StopListen() {
-	if wrapper == nil || wrapper.server == nil {
+	DefaultServer.StopListen()
+}
+
+// StopListen closes the server and its connections right away. Listen returns then.
+func (s *Server) StopListen() {
+	server := s.takeServer()
+	if server == nil {
 		return
 	}
-	if err := wrapper.server.Close(); err != nil {
+	if err := server.Close(); err != nil {
 		log.Error().Err(err).Msgf("Failed to stop extension server")
 	}
-	wrapper = nil
+}
+
+// takeServer returns the running http.Server, if any, which is forgotten, so that it is stopped only once.
+func (s *Server) takeServer() *http.Server {
+	s.serveCond.L.Lock()
+	defer s.serveCond.L.Unlock()
+	if s.wrapper == nil {
+		return nil
+	}
+	server := s.wrapper.server
+	s.wrapper = nil
+	s.serving = false
+	return server
 }
 
 type forwardToZeroLogWriter struct {
@@ -209,13 +231,14 @@ func (fw *forwardToZeroLogWriter) Write(p []byte) (n int, err error) {
 	return len([]byte(trimmed)), nil
 }
 
-func prepareHttpServer(port int) (*httpServerWrapper, error) {
+func prepareHttpServer(port int, handler http.Handler) (*httpServerWrapper, error) {
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return nil, err
 	}
 
 	server := &http.Server{
+		Handler:  handler,
 		ErrorLog: stdLog.New(&forwardToZeroLogWriter{}, "", 0),
 	}
 
@@ -225,10 +248,11 @@ func prepareHttpServer(port int) (*httpServerWrapper, error) {
 			return server.Serve(listener)
 		},
 		server: server,
+		addr:   listener.Addr(),
 	}, nil
 }
 
-func prepareUnixSocketServer(path string) (*httpServerWrapper, error) {
+func prepareUnixSocketServer(path string, handler http.Handler) (*httpServerWrapper, error) {
 	if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
 		err = os.MkdirAll(filepath.Dir(path), 0755)
 		if err != nil {
@@ -244,6 +268,7 @@ func prepareUnixSocketServer(path string) (*httpServerWrapper, error) {
 	}
 
 	server := &http.Server{
+		Handler:  handler,
 		ErrorLog: stdLog.New(&forwardToZeroLogWriter{}, "", 0),
 	}
 
@@ -253,10 +278,11 @@ func prepareUnixSocketServer(path string) (*httpServerWrapper, error) {
 			return server.Serve(unixListener)
 		},
 		server: server,
+		addr:   unixListener.Addr(),
 	}, nil
 }
 
-func prepareHttpsServer(port int, spec ListenSpecification) (*httpServerWrapper, error) {
+func prepareHttpsServer(port int, spec ListenSpecification, handler http.Handler) (*httpServerWrapper, error) {
 	certReloader := NewCertReloader(spec.TlsServerCert, spec.TlsServerKey)
 
 	if _, err := certReloader.GetCertificate(nil); err != nil {
@@ -280,6 +306,7 @@ func prepareHttpsServer(port int, spec ListenSpecification) (*httpServerWrapper,
 	}
 
 	server := &http.Server{
+		Handler:   handler,
 		TLSConfig: &tlsConfig,
 		ErrorLog:  stdLog.New(&forwardToZeroLogWriter{}, "", 0),
 	}
@@ -289,6 +316,7 @@ func prepareHttpsServer(port int, spec ListenSpecification) (*httpServerWrapper,
 			return server.ServeTLS(listener, "", "")
 		},
 		server: server,
+		addr:   listener.Addr(),
 	}, nil
 }
 
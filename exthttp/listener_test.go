@@ -127,7 +127,7 @@ func TestStartHttpsServerMustFailWhenCertificateCannotBeFound(t *testing.T) {
 	t.Setenv("STEADYBIT_EXTENSION_TLS_SERVER_KEY", key)
 	t.Setenv("STEADYBIT_EXTENSION_TLS_SERVER_CERT", filepath.Join(t.TempDir(), "unknown.pem"))
 
-	err = listen(ListenOpts{Port: port})
+	err = NewServer().listen(ListenOpts{Port: port})
 
 	var expected string
 
@@ -151,7 +151,7 @@ func TestStartHttpsServerMustFailWhenKeyCannotBeFound(t *testing.T) {
 	defer func() { http.DefaultServeMux = old }()
 	t.Setenv("STEADYBIT_EXTENSION_TLS_SERVER_KEY", filepath.Join(t.TempDir(), "unknown.pem"))
 	t.Setenv("STEADYBIT_EXTENSION_TLS_SERVER_CERT", cert)
-	err = listen(ListenOpts{Port: port})
+	err = NewServer().listen(ListenOpts{Port: port})
 
 	var expected string
 
@@ -278,15 +278,13 @@ func Test_hidePprofHandlers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			old := http.DefaultServeMux
-			defer func() { http.DefaultServeMux = old }()
-
 			r, _ := http.NewRequest("GET", "/debug/pprof/", nil)
 			w := httptest.NewRecorder()
 
-			hidePprofHandlers(ListenSpecification{EnablePprof: tt.enabled})
+			s := NewServer()
+			s.hidePprofHandlers(ListenSpecification{EnablePprof: tt.enabled})
 
-			http.DefaultServeMux.ServeHTTP(w, r)
+			s.ServeHTTP(w, r)
 
 			assert.Equal(t, tt.wantedStatus, w.Result().StatusCode)
 		})
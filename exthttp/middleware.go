@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/gzhttp"
+	"github.com/rs/zerolog"
+)
+
+// Names of the built-in middleware, see DefaultMiddleware.
+const (
+	MiddlewarePanicRecovery  = "PanicRecovery"
+	MiddlewareGzip           = "Gzip"
+	MiddlewareRequestTimeout = "RequestTimeoutHeaderAware"
+	MiddlewareLogRequest     = "LogRequest"
+)
+
+// Middleware wraps the handlers of a Server. The name identifies it within a chain, so that it can be replaced or
+// removed for individual routes, see ReplaceMiddleware and WithoutMiddleware.
+type Middleware struct {
+	Name string
+	Wrap func(next http.Handler) http.Handler
+}
+
+// DefaultMiddleware returns the chain a Server starts with, outermost first: panic recovery, gzip compression, the
+// request timeout headers and request logging with defaultLevel.
+func DefaultMiddleware(defaultLevel zerolog.Level) []Middleware {
+	return []Middleware{
+		PanicRecoveryMiddleware(),
+		GzipMiddleware(),
+		RequestTimeoutMiddleware(),
+		LogRequestMiddleware(defaultLevel),
+	}
+}
+
+// PanicRecoveryMiddleware responds with an ExtensionError if the handler panics, see PanicRecovery.
+func PanicRecoveryMiddleware() Middleware {
+	return Middleware{Name: MiddlewarePanicRecovery, Wrap: PanicRecovery}
+}
+
+// GzipMiddleware compresses responses if the client accepts it.
+func GzipMiddleware() Middleware {
+	return Middleware{Name: MiddlewareGzip, Wrap: func(next http.Handler) http.Handler {
+		return gzhttp.GzipHandler(next)
+	}}
+}
+
+// RequestTimeoutMiddleware applies the timeout requested through the Request-Timeout header, see
+// RequestTimeoutHeaderAware.
+func RequestTimeoutMiddleware() Middleware {
+	return Middleware{Name: MiddlewareRequestTimeout, Wrap: func(next http.Handler) http.Handler {
+		return RequestTimeoutHeaderAware(next)
+	}}
+}
+
+// LogRequestMiddleware logs the requests, GET requests at debug and all others at defaultLevel, see
+// LogRequestWithDefaultLogLevel. The request body it reads is passed on to the Handler of the route.
+func LogRequestMiddleware(defaultLevel zerolog.Level) Middleware {
+	return Middleware{Name: MiddlewareLogRequest, Wrap: func(next http.Handler) http.Handler {
+		return logRequest(next, defaultLevel)
+	}}
+}
+
+// RouteOption configures a single route, see Server.RegisterHttpHandler.
+type RouteOption func(route *routeConfig)
+
+type routeConfig struct {
+	middleware []Middleware
+	body       bodyConfig
+}
+
+// WithMiddleware appends middleware to the chain of the route, so that it is run after (inside of) the middleware of
+// the server, in the given order.
+func WithMiddleware(middleware ...Middleware) RouteOption {
+	return func(route *routeConfig) {
+		route.middleware = append(route.middleware, middleware...)
+	}
+}
+
+// WithoutMiddleware removes the middleware with the given names from the chain of the route.
+func WithoutMiddleware(names ...string) RouteOption {
+	return func(route *routeConfig) {
+		route.middleware = slices.DeleteFunc(route.middleware, func(m Middleware) bool {
+			return slices.Contains(names, m.Name)
+		})
+	}
+}
+
+// ReplaceMiddleware replaces the middleware with the same name in the chain of the route, keeping its position. The
+// chain is left as it is if it does not contain such middleware.
+func ReplaceMiddleware(middleware Middleware) RouteOption {
+	return func(route *routeConfig) {
+		for i, m := range route.middleware {
+			if m.Name == middleware.Name {
+				route.middleware[i] = middleware
+			}
+		}
+	}
+}
+
+// WithLogLevel logs the requests of the route at level, except GET requests, which are logged at debug.
+func WithLogLevel(level zerolog.Level) RouteOption {
+	return ReplaceMiddleware(LogRequestMiddleware(level))
+}
+
+// Use appends middleware to the chain of the server. It applies to all routes, including the ones registered before.
+func (s *Server) Use(middleware ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+	s.middlewareVersion.Add(1)
+}
+
+// SetMiddleware replaces the chain of the server, outermost first. It applies to all routes, including the ones
+// registered before.
+func (s *Server) SetMiddleware(middleware ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = slices.Clone(middleware)
+	s.middlewareVersion.Add(1)
+}
+
+// Middleware returns the chain of the server, outermost first.
+func (s *Server) Middleware() []Middleware {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.middleware)
+}
+
+// route wraps handler in the middleware of the server, as configured by opts. The chain is built when the route is
+// served, and rebuilt once the middleware of the server changed, so that Use and SetMiddleware apply to it.
+func (s *Server) route(handler http.Handler, opts []RouteOption) http.Handler {
+	return &routeHandler{server: s, handler: handler, opts: opts}
+}
+
+type routeHandler struct {
+	server  *Server
+	handler http.Handler
+	opts    []RouteOption
+	chain   atomic.Pointer[routeChain]
+}
+
+// routeChain is the handler of a route wrapped in the given version of the middleware of the server.
+type routeChain struct {
+	version uint64
+	handler http.Handler
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	chain := h.chain.Load()
+	if chain == nil || chain.version != h.server.middlewareVersion.Load() {
+		chain = h.build()
+		h.chain.Store(chain)
+	}
+	chain.handler.ServeHTTP(w, r)
+}
+
+func (h *routeHandler) build() *routeChain {
+	h.server.mu.Lock()
+	route := routeConfig{middleware: slices.Clone(h.server.middleware)}
+	version := h.server.middlewareVersion.Load()
+	h.server.mu.Unlock()
+
+	for _, opt := range h.opts {
+		opt(&route)
+	}
+	handler := h.handler
+	for _, m := range slices.Backward(route.middleware) {
+		handler = m.Wrap(handler)
+	}
+	return &routeChain{version: version, handler: withBodyConfig(handler, route.body)}
+}
+
+type requestBodyKey struct{}
+
+// withRequestBody passes the body read by a middleware on to the Handler.
+func withRequestBody(r *http.Request, body []byte) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestBodyKey{}, body))
+}
+
+// asHttpHandler adapts handler to an http.Handler. It is passed the body read by the request logging, the body is read
+// by the adapter if the logging was removed. Errors written through WriteError use application/problem+json if the
+// request accepts it.
+func asHttpHandler(handler Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if acceptsProblemJson(r) {
+			w = problemJsonWriter{w}
+		}
+		body, ok := r.Context().Value(requestBodyKey{}).([]byte)
+		if !ok {
+			if body, ok = readBody(w, r); !ok {
+				return
+			}
+		}
+		handler(w, r, body)
+	})
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingMiddleware appends its name to the X-Chain response header, so that the order of the chain can be checked.
+func recordingMiddleware(name string) Middleware {
+	return Middleware{Name: name, Wrap: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(s *Server)
+		opts      []RouteOption
+		wantChain []string
+	}{
+		{
+			name:      "server middleware runs after the defaults",
+			setup:     func(s *Server) { s.Use(recordingMiddleware("auth"), recordingMiddleware("metrics")) },
+			wantChain: []string{"auth", "metrics"},
+		},
+		{
+			name:      "route middleware runs after the server middleware",
+			setup:     func(s *Server) { s.Use(recordingMiddleware("auth")) },
+			opts:      []RouteOption{WithMiddleware(recordingMiddleware("route"))},
+			wantChain: []string{"auth", "route"},
+		},
+		{
+			name:      "explicit chain",
+			setup:     func(s *Server) { s.SetMiddleware(recordingMiddleware("second"), recordingMiddleware("first")) },
+			wantChain: []string{"second", "first"},
+		},
+		{
+			name:      "replaced middleware keeps its position",
+			setup:     func(s *Server) { s.Use(recordingMiddleware("auth"), recordingMiddleware("metrics")) },
+			opts:      []RouteOption{ReplaceMiddleware(Middleware{Name: "auth", Wrap: recordingMiddleware("basic-auth").Wrap})},
+			wantChain: []string{"basic-auth", "metrics"},
+		},
+		{
+			name:      "built-in replaced",
+			opts:      []RouteOption{ReplaceMiddleware(recordingMiddleware(MiddlewareGzip))},
+			wantChain: []string{MiddlewareGzip},
+		},
+		{
+			name:      "removed middleware",
+			setup:     func(s *Server) { s.Use(recordingMiddleware("auth"), recordingMiddleware("metrics")) },
+			opts:      []RouteOption{WithoutMiddleware("auth")},
+			wantChain: []string{"metrics"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			s.RegisterHttpHandler("POST /", func(w http.ResponseWriter, r *http.Request, body []byte) {
+				w.WriteHeader(http.StatusNoContent)
+			}, tt.opts...)
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.wantChain, w.Header().Values("X-Chain"))
+		})
+	}
+}
+
+func TestServerMiddlewareDoesNotAffectOtherRoutes(t *testing.T) {
+	s := NewServer()
+	s.RegisterHttpHandler("/with", func(w http.ResponseWriter, r *http.Request, body []byte) {},
+		WithMiddleware(recordingMiddleware("route")))
+	s.RegisterHttpHandler("/without", func(w http.ResponseWriter, r *http.Request, body []byte) {})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/without", nil))
+	assert.Empty(t, w.Header().Values("X-Chain"))
+	assert.Len(t, s.Middleware(), 4)
+}
+
+func TestServerMiddlewareAppliesToRegisteredRoutes(t *testing.T) {
+	s := NewServer()
+	s.RegisterHttpHandler("/", func(w http.ResponseWriter, r *http.Request, body []byte) {},
+		WithMiddleware(recordingMiddleware("route")))
+	serve := func() []string {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Header().Values("X-Chain")
+	}
+	assert.Equal(t, []string{"route"}, serve())
+
+	s.Use(recordingMiddleware("auth"))
+	assert.Equal(t, []string{"auth", "route"}, serve())
+
+	s.SetMiddleware(recordingMiddleware("rate-limit"))
+	assert.Equal(t, []string{"rate-limit", "route"}, serve())
+}
+
+func TestHandlerReceivesBody(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []RouteOption
+	}{
+		{
+			name: "read by the request logging",
+		},
+		{
+			name: "request logging removed",
+			opts: []RouteOption{WithoutMiddleware(MiddlewareLogRequest)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			var received string
+			s.RegisterHttpHandler("POST /", func(w http.ResponseWriter, r *http.Request, body []byte) {
+				received = string(body)
+			}, tt.opts...)
+
+			s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`)))
+
+			assert.Equal(t, `{"a":1}`, received)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/steadybit/extension-kit/extutil"
+)
+
+var (
+	// RequestLogRedactor masks secrets in JSON request bodies before they are logged at debug level. Extensions can
+	// replace it to mask their own keys and paths, e.g.,
+	// extutil.NewRedactor(append(extutil.DefaultSensitiveKeys, "dsn"), "$.config.headers").
+	RequestLogRedactor = extutil.DefaultRedactor
+	// SensitiveHeaders are masked when request headers are logged at debug level, in addition to the headers whose
+	// names match the keys of RequestLogRedactor, e.g., X-Auth-Token. The scheme of authorization headers remains
+	// visible.
+	SensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+)
+
+// loggedHeaders returns a copy of header with the values of SensitiveHeaders and of the headers matching the keys of
+// RequestLogRedactor masked.
+func loggedHeaders(header http.Header) http.Header {
+	logged := header.Clone()
+	for name, values := range logged {
+		if !RequestLogRedactor.MatchesKey(name) && !slices.ContainsFunc(SensitiveHeaders, func(sensitive string) bool {
+			return strings.EqualFold(sensitive, name)
+		}) {
+			continue
+		}
+		for i, value := range values {
+			values[i] = maskHeaderValue(name, value)
+		}
+	}
+	return logged
+}
+
+func maskHeaderValue(name, value string) string {
+	if strings.HasSuffix(name, "Authorization") {
+		if scheme, credentials, ok := strings.Cut(value, " "); ok {
+			return scheme + " " + RequestLogRedactor.Mask(credentials)
+		}
+	}
+	return RequestLogRedactor.Mask(value)
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLoggingRedactsSecrets(t *testing.T) {
+	var logs bytes.Buffer
+	oldLogger := log.Logger
+	defer func() { log.Logger = oldLogger }()
+	log.Logger = zerolog.New(&logs).Level(zerolog.DebugLevel)
+
+	var received string
+	h := LogRequestWithDefaultLogLevel(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		received = string(body)
+	}, zerolog.InfoLevel)
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"config":{"apiToken":"s3cr3t","duration":1000}}`))
+	r.Header.Set("Authorization", "Bearer abcdef")
+	r.Header.Set("Cookie", "session=1")
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("X-Auth-Token", "t0k3n")
+	r.Header.Set("X-Vault-Token", "v4ult")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, `{"config":{"apiToken":"s3cr3t","duration":1000}}`, received, "the handler gets the body unchanged")
+
+	var entry struct {
+		Body    string              `json:"body"`
+		Headers map[string][]string `json:"headers"`
+	}
+	line, _, _ := strings.Cut(logs.String(), "\n")
+	require.NoError(t, json.Unmarshal([]byte(line), &entry))
+	assert.Equal(t, `{"config":{"apiToken":"******","duration":1000}}`, entry.Body)
+	assert.Equal(t, []string{"Bearer ******"}, entry.Headers["Authorization"])
+	assert.Equal(t, []string{"*********"}, entry.Headers["Cookie"])
+	assert.Equal(t, []string{"*****"}, entry.Headers["X-Auth-Token"], "headers matching the redactor's keys are masked")
+	assert.Equal(t, []string{"*****"}, entry.Headers["X-Vault-Token"])
+	assert.Equal(t, []string{"application/json"}, entry.Headers["Accept"])
+	assert.Equal(t, "Bearer abcdef", r.Header.Get("Authorization"), "the request headers are unchanged")
+}
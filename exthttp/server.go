@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Server is an extension HTTP server. It owns the ServeMux its handlers are registered on, the middleware wrapped
+// around them and the listener, so that multiple servers can be run side by side, e.g., in tests. Patterns are those of
+// http.ServeMux and may include a method and wildcards, like "GET /items/{id}".
+//
+// The package-level functions like RegisterHttpHandler and Listen use DefaultServer.
+type Server struct {
+	// mux is nil for DefaultServer, which uses http.DefaultServeMux, see serveMux.
+	mux                 *http.ServeMux
+	pprofEnabled        atomic.Bool
+	shutdownHandlerName string
+
+	mu         sync.Mutex
+	middleware []Middleware
+	// middlewareVersion is incremented whenever middleware changes, so that routes rebuild their chain.
+	middlewareVersion atomic.Uint64
+
+	serveCond *sync.Cond
+	serving   bool
+	wrapper   *httpServerWrapper
+}
+
+// DefaultServer is the Server used by the package-level functions. It registers its handlers on http.DefaultServeMux,
+// so that handlers registered there directly, e.g., through http.Handle, keep working and the most specific pattern
+// wins among both.
+var DefaultServer = newServer(nil, "StopExtensionHTTP")
+
+// NewServer creates a Server without any handlers.
+func NewServer() *Server {
+	s := newServer(http.NewServeMux(), "")
+	s.shutdownHandlerName = fmt.Sprintf("StopExtensionHTTP-%p", s)
+	return s
+}
+
+func newServer(mux *http.ServeMux, shutdownHandlerName string) *Server {
+	return &Server{
+		mux:                 mux,
+		shutdownHandlerName: shutdownHandlerName,
+		middleware:          DefaultMiddleware(zerolog.InfoLevel),
+		serveCond:           sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// serveMux returns the ServeMux the handlers of the server are registered on.
+func (s *Server) serveMux() *http.ServeMux {
+	if s.mux == nil {
+		return http.DefaultServeMux
+	}
+	return s.mux
+}
+
+// Handle registers handler for pattern as it is, without any middleware. Like http.ServeMux.Handle, it panics if a
+// handler is registered for the pattern already.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.serveMux().Handle(pattern, handler)
+}
+
+// RegisterHttpHandler registers a handler for the given pattern. The handler is wrapped in the middleware of the server,
+// by default panic recovery, gzip compression and request logging (see DefaultMiddleware), which can be adjusted for
+// the route through opts.
+func (s *Server) RegisterHttpHandler(pattern string, handler Handler, opts ...RouteOption) {
+	s.Handle(pattern, s.route(asHttpHandler(handler), opts))
+}
+
+// RegisterHttpHandlerWithLogLevel registers a handler for the given pattern like RegisterHttpHandler, but logs the
+// requests with the given log level.
+func (s *Server) RegisterHttpHandlerWithLogLevel(pattern string, handler Handler, defaultLevel zerolog.Level) {
+	s.RegisterHttpHandler(pattern, handler, WithLogLevel(defaultLevel))
+}
+
+// ServeHTTP dispatches the request to the handler registered for the pattern matching it. The pprof handlers are only
+// served if enabled through STEADYBIT_EXTENSION_ENABLE_PPROF.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isPprofPath(r.URL.Path) {
+		if s.pprofEnabled.Load() {
+			http.DefaultServeMux.ServeHTTP(w, r)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+	s.serveMux().ServeHTTP(w, r)
+}
+
+func isPprofPath(path string) bool {
+	return path == "/debug/pprof" || strings.HasPrefix(path, "/debug/pprof/")
+}
+
+// Addr returns the address the server listens on, nil if it is not listening.
+func (s *Server) Addr() net.Addr {
+	s.serveCond.L.Lock()
+	defer s.serveCond.L.Unlock()
+	if s.wrapper == nil {
+		return nil
+	}
+	return s.wrapper.addr
+}
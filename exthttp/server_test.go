@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRoutes(t *testing.T) {
+	s := NewServer()
+	s.RegisterHttpHandler("GET /items/{id}", func(w http.ResponseWriter, r *http.Request, body []byte) {
+		WriteBody(w, r.PathValue("id"))
+	})
+	s.RegisterHttpHandler("POST /items", func(w http.ResponseWriter, r *http.Request, body []byte) {
+		WriteBody(w, string(body))
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "path value",
+			method:     http.MethodGet,
+			path:       "/items/42",
+			wantStatus: http.StatusOK,
+			wantBody:   "\"42\"\n",
+		},
+		{
+			name:       "body",
+			method:     http.MethodPost,
+			path:       "/items",
+			body:       "item",
+			wantStatus: http.StatusOK,
+			wantBody:   "\"item\"\n",
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodDelete,
+			path:       "/items/42",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			path:       "/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	first := NewServer()
+	second := NewServer()
+	for i, s := range []*Server{first, second} {
+		s.RegisterHttpHandler("GET /name", GetterAsHandler(func() string { return fmt.Sprintf("server %d", i) }))
+		go s.Listen(ListenOpts{Port: 0})
+		s.WaitForServe()
+		defer s.StopListen()
+	}
+
+	for i, s := range []*Server{first, second} {
+		res, err := http.Get(fmt.Sprintf("http://%s/name", s.Addr()))
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, fmt.Sprintf("\"server %d\"\n", i), string(body))
+	}
+}
+
+func TestDefaultServerFallsBackToDefaultServeMux(t *testing.T) {
+	old := http.DefaultServeMux
+	defer func() { http.DefaultServeMux = old }()
+	http.DefaultServeMux = http.NewServeMux()
+	http.HandleFunc("/legacy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	DefaultServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	w = httptest.NewRecorder()
+	NewServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDefaultServerServesMostSpecificPattern(t *testing.T) {
+	old := http.DefaultServeMux
+	defer func() { http.DefaultServeMux = old }()
+	http.DefaultServeMux = http.NewServeMux()
+	// Extensions register their index as catch-all, which must not shadow handlers registered directly.
+	RegisterHttpHandler("/", func(w http.ResponseWriter, r *http.Request, body []byte) {
+		w.WriteHeader(298)
+	})
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(299)
+	})
+
+	w := httptest.NewRecorder()
+	DefaultServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, 299, w.Code)
+
+	w = httptest.NewRecorder()
+	DefaultServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, 298, w.Code)
+
+	assert.Panics(t, func() { Handle("/metrics", http.NotFoundHandler()) }, "duplicate patterns are rejected")
+}
+
+func TestServerStopListen(t *testing.T) {
+	s := NewServer()
+	done := make(chan struct{})
+	go func() {
+		s.Listen(ListenOpts{Port: 0})
+		close(done)
+	}()
+	s.WaitForServe()
+	require.NotNil(t, s.Addr())
+
+	s.StopListen()
+	<-done
+	assert.Nil(t, s.Addr())
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/steadybit/extension-kit"
+)
+
+// TypedHandlerOption configures a TypedHandler.
+type TypedHandlerOption func(cfg *typedHandlerConfig)
+
+type typedHandlerConfig struct {
+	allowUnknownFields bool
+	status             int
+	contentType        string
+	errorMapper        func(err error) extension_kit.ExtensionError
+}
+
+// AllowUnknownFields accepts request bodies with fields the request type does not have, they are rejected by default.
+func AllowUnknownFields() TypedHandlerOption {
+	return func(cfg *typedHandlerConfig) {
+		cfg.allowUnknownFields = true
+	}
+}
+
+// WithResponseStatus sets the status of successful responses, 200 by default. No body is written for 204.
+func WithResponseStatus(status int) TypedHandlerOption {
+	return func(cfg *typedHandlerConfig) {
+		cfg.status = status
+	}
+}
+
+// WithResponseContentType sets the content type of successful responses, application/json by default.
+func WithResponseContentType(contentType string) TypedHandlerOption {
+	return func(cfg *typedHandlerConfig) {
+		cfg.contentType = contentType
+	}
+}
+
+// WithErrorMapper sets how errors returned by the handler are turned into the ExtensionError responded with, see
+// DefaultErrorMapper.
+func WithErrorMapper(mapper func(err error) extension_kit.ExtensionError) TypedHandlerOption {
+	return func(cfg *typedHandlerConfig) {
+		cfg.errorMapper = mapper
+	}
+}
+
+// DefaultErrorMapper returns the ExtensionError the error is or wraps, see extension_kit.WrapError. Deadlines exceeded
+// are reported as timeout (504), any other error as internal error (500) with the error as title.
+func DefaultErrorMapper(err error) extension_kit.ExtensionError {
+	var extErr *extension_kit.ExtensionError
+	if errors.As(err, &extErr) {
+		return *extErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return extension_kit.ToTimeoutError("Request timed out", err)
+	}
+	return *extension_kit.WrapError(err)
+}
+
+// TypedHandler turns a function on typed requests and responses into a Handler. The request body is decoded as JSON
+// into Req, strictly: unknown fields (unless AllowUnknownFields is given), trailing data and content types other than
+// JSON are rejected with a 400 or 415 ExtensionError. An empty body is decoded as the zero value of Req. The result is
+// written as JSON, errors are mapped to an ExtensionError (see WithErrorMapper) and written through
+// WriteErrorForRequest.
+func TypedHandler[Req, Res any](handler func(ctx context.Context, req Req) (Res, error), opts ...TypedHandlerOption) Handler {
+	cfg := typedHandlerConfig{
+		status:      http.StatusOK,
+		contentType: "application/json",
+		errorMapper: DefaultErrorMapper,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, body []byte) {
+		var req Req
+		if err := decodeRequest(r, body, &req, cfg.allowUnknownFields); err != nil {
+			WriteErrorForRequest(w, r, *err)
+			return
+		}
+
+		res, err := handler(r.Context(), req)
+		if err != nil {
+			WriteErrorForRequest(w, r, cfg.errorMapper(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", cfg.contentType)
+		if cfg.status == http.StatusNoContent {
+			w.WriteHeader(cfg.status)
+			return
+		}
+		encoded, err := json.Marshal(res)
+		if err != nil {
+			WriteErrorForRequest(w, r, extension_kit.ToError("Failed to encode response", err))
+			return
+		}
+		w.WriteHeader(cfg.status)
+		if _, err := w.Write(append(encoded, '\n')); err != nil {
+			log.Err(err).Msgf("Failed to write response body")
+		}
+	}
+}
+
+func decodeRequest(r *http.Request, body []byte, req any, allowUnknownFields bool) *extension_kit.ExtensionError {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !isJsonContentType(contentType) {
+		return new(extension_kit.ToError("Unsupported content type", fmt.Errorf("expected JSON, got %s", contentType)).
+			WithStatus(http.StatusUnsupportedMediaType))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if !allowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(req); err != nil {
+		return new(extension_kit.ToBadRequestError("Failed to parse request body", err))
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return new(extension_kit.ToBadRequestError("Failed to parse request body", errors.New("unexpected data after the JSON value")))
+	}
+	return nil
+}
+
+func isJsonContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package exthttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/steadybit/extension-kit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+type greetResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func greet(_ context.Context, req greetRequest) (greetResponse, error) {
+	switch req.Name {
+	case "nobody":
+		return greetResponse{}, fmt.Errorf("lookup failed: %w", extension_kit.ToNotFoundError("Unknown name", nil))
+	case "slow":
+		return greetResponse{}, context.DeadlineExceeded
+	case "broken":
+		return greetResponse{}, errors.New("boom")
+	}
+	return greetResponse{Greeting: "Hello " + req.Name}, nil
+}
+
+func TestTypedHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []TypedHandlerOption
+		contentType     string
+		body            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantErrorTitle  string
+	}{
+		{
+			name:            "success",
+			body:            `{"name":"Alice"}`,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "{\"greeting\":\"Hello Alice\"}\n",
+		},
+		{
+			name:            "empty body",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "{\"greeting\":\"Hello \"}\n",
+		},
+		{
+			name:           "unknown field",
+			body:           `{"name":"Alice","age":42}`,
+			wantStatus:     http.StatusBadRequest,
+			wantErrorTitle: "Failed to parse request body",
+		},
+		{
+			name:            "unknown field allowed",
+			opts:            []TypedHandlerOption{AllowUnknownFields()},
+			body:            `{"name":"Alice","age":42}`,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "{\"greeting\":\"Hello Alice\"}\n",
+		},
+		{
+			name:           "trailing data",
+			body:           `{"name":"Alice"} {}`,
+			wantStatus:     http.StatusBadRequest,
+			wantErrorTitle: "Failed to parse request body",
+		},
+		{
+			name:           "malformed",
+			body:           `{"name":`,
+			wantStatus:     http.StatusBadRequest,
+			wantErrorTitle: "Failed to parse request body",
+		},
+		{
+			name:           "unsupported content type",
+			contentType:    "text/plain",
+			body:           `{"name":"Alice"}`,
+			wantStatus:     http.StatusUnsupportedMediaType,
+			wantErrorTitle: "Unsupported content type",
+		},
+		{
+			name:            "json suffix content type",
+			contentType:     "application/vnd.steadybit+json; charset=utf-8",
+			body:            `{"name":"Alice"}`,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "{\"greeting\":\"Hello Alice\"}\n",
+		},
+		{
+			name:           "wrapped extension error",
+			body:           `{"name":"nobody"}`,
+			wantStatus:     http.StatusNotFound,
+			wantErrorTitle: "Unknown name",
+		},
+		{
+			name:           "deadline exceeded",
+			body:           `{"name":"slow"}`,
+			wantStatus:     http.StatusGatewayTimeout,
+			wantErrorTitle: "Request timed out",
+		},
+		{
+			name:           "other error",
+			body:           `{"name":"broken"}`,
+			wantStatus:     http.StatusInternalServerError,
+			wantErrorTitle: "boom",
+		},
+		{
+			name: "custom error mapper",
+			opts: []TypedHandlerOption{WithErrorMapper(func(err error) extension_kit.ExtensionError {
+				return extension_kit.ToUnavailableError("Mapped", err)
+			})},
+			body:           `{"name":"broken"}`,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantErrorTitle: "Mapped",
+		},
+		{
+			name:            "custom status and content type",
+			opts:            []TypedHandlerOption{WithResponseStatus(http.StatusCreated), WithResponseContentType("application/vnd.steadybit+json")},
+			body:            `{"name":"Alice"}`,
+			wantStatus:      http.StatusCreated,
+			wantContentType: "application/vnd.steadybit+json",
+			wantBody:        "{\"greeting\":\"Hello Alice\"}\n",
+		},
+		{
+			name:            "no content",
+			opts:            []TypedHandlerOption{WithResponseStatus(http.StatusNoContent)},
+			body:            `{"name":"Alice"}`,
+			wantStatus:      http.StatusNoContent,
+			wantContentType: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer()
+			s.RegisterHttpHandler("POST /greet", TypedHandler(greet, tt.opts...))
+
+			r := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantErrorTitle != "" {
+				var extErr extension_kit.ExtensionError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &extErr))
+				assert.Equal(t, tt.wantErrorTitle, extErr.Title)
+				return
+			}
+			assert.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
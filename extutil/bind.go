@@ -0,0 +1,418 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Bind decodes an action or discovery configuration into the struct pointed to by target. In contrast to
+// extconversion.Convert, values are coerced like in Get, e.g. numbers may be sent as strings and booleans as "true".
+// time.Duration fields are decoded like in GetDuration.
+//
+// Fields are named by their json tag and can be constrained through the config tag, which holds a comma-separated list
+// of options:
+//
+//	type Config struct {
+//		Duration time.Duration     `json:"duration" config:"required,min=1s"`
+//		Workers  int               `json:"workers" config:"default=1,min=1,max=64"`
+//		Mode     string            `json:"mode" config:"enum=cpu|memory|io"`
+//		Headers  map[string]string `json:"headers"`
+//	}
+//
+// The supported options are:
+//   - required: the value must be present and, for strings, slices and maps, not be empty.
+//   - default=value: the value to use if the config has none. It is parsed like a config value.
+//   - min=value, max=value: bounds for numbers and durations, or for the length of strings, slices and maps.
+//   - enum=a|b|c: the allowed values.
+//
+// Fields without a value in the config keep their current value. Nested structs, pointers, slices and maps (either
+// as object or as key/value array, see ToKeyValue) are supported. If a nested object is missing, the fields of a
+// nested struct are still checked for being required and get their defaults, while nested struct pointers stay nil.
+// All problems are collected and returned as a single *extension_kit.ExtensionError, see Validator.ToError. Other
+// errors indicate an unsupported target or a malformed tag. The tags are validated once per type, including those of
+// nested structs and of fields without a value.
+func Bind(config map[string]any, target any) error {
+	v := NewValidator(config)
+	if err := BindValidated(v, target); err != nil {
+		return err
+	}
+	if extErr := v.ToError("Invalid configuration"); extErr != nil {
+		return extErr
+	}
+	return nil
+}
+
+// BindValidated behaves like Bind, but records problems with the Validator. It only returns an error for an
+// unsupported target or a malformed tag.
+func BindValidated(v *Validator, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non-nil pointer to a struct, got %T", target)
+	}
+	fields, err := bindFields(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	bindStruct(v, fields, rv.Elem())
+	return nil
+}
+
+type bindOptions struct {
+	required     bool
+	defaultValue *string
+	min          *bindBound
+	max          *bindBound
+	enum         []string
+}
+
+// bindBound is a min or max option. The value of durations is given in nanoseconds.
+type bindBound struct {
+	text  string
+	value float64
+}
+
+// parseBindOptions parses the config tag of a field of type t. The options are validated against the type, so that a
+// malformed tag is reported even if the config has no value for the field.
+func parseBindOptions(tag string, t reflect.Type) (bindOptions, error) {
+	var opts bindOptions
+	for option := range strings.SplitSeq(tag, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(option), "=")
+		var err error
+		switch {
+		case name == "":
+		case name == "required" && !hasValue:
+			opts.required = true
+		case name == "default" && hasValue:
+			opts.defaultValue = new(value)
+			err = checkDefault(value, t)
+		case name == "min" && hasValue:
+			opts.min, err = parseBound(name, value, t)
+		case name == "max" && hasValue:
+			opts.max, err = parseBound(name, value, t)
+		case name == "enum" && hasValue:
+			opts.enum = strings.Split(value, "|")
+		default:
+			err = fmt.Errorf("unknown config tag option %q", option)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func parseBound(name, value string, t reflect.Type) (*bindBound, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		d, err := parseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return &bindBound{text: value, value: float64(d)}, nil
+	case isNumber(t.Kind()) || t.Kind() == reflect.String || t.Kind() == reflect.Slice || t.Kind() == reflect.Map:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", name, value)
+		}
+		return &bindBound{text: value, value: f}, nil
+	default:
+		return nil, fmt.Errorf("%s is not supported for %s", name, t)
+	}
+}
+
+func checkDefault(value string, t reflect.Type) error {
+	v := NewValidator(nil)
+	err := bindValue(v, "default", value, reflect.New(t).Elem())
+	if err == nil && len(*v.errors) > 0 {
+		err = errors.New((*v.errors)[0].Reason)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default %q: %w", value, err)
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		return field.Name, true
+	}
+	return name, true
+}
+
+// boundField is a field of a struct bound by Bind, index is the index sequence for reflect.Value.FieldByIndex.
+type boundField struct {
+	index []int
+	name  string
+	opts  bindOptions
+}
+
+type bindPlan struct {
+	fields []boundField
+	err    error
+}
+
+var bindPlans sync.Map // reflect.Type -> bindPlan
+
+// bindFields returns the fields of the struct type t. Their tags are parsed once per type, including the tags of the
+// struct types reachable from t.
+func bindFields(t reflect.Type) ([]boundField, error) {
+	if plan, ok := bindPlans.Load(t); ok {
+		return plan.(bindPlan).fields, plan.(bindPlan).err
+	}
+	fields, err := newBindFields(t, nil, map[reflect.Type]bool{})
+	bindPlans.Store(t, bindPlan{fields: fields, err: err})
+	return fields, err
+}
+
+func newBindFields(t reflect.Type, index []int, visited map[reflect.Type]bool) ([]boundField, error) {
+	visited[t] = true
+	var fields []boundField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fieldIndex := append(slices.Clone(index), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			// Like encoding/json, the fields of embedded structs are promoted, even if the struct type is unexported.
+			embedded, err := newBindFields(field.Type, fieldIndex, visited)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+
+		opts, err := parseBindOptions(field.Tag.Get("config"), field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if nested := nestedStructType(field.Type); nested != nil && !visited[nested] {
+			if _, err := newBindFields(nested, nil, visited); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		fields = append(fields, boundField{index: fieldIndex, name: name, opts: opts})
+	}
+	return fields, nil
+}
+
+// nestedStructType returns the struct type bound for values of type t, if any.
+func nestedStructType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+func bindStruct(v *Validator, fields []boundField, target reflect.Value) {
+	for _, field := range fields {
+		bindField(v, field.name, field.opts, target.FieldByIndex(field.index))
+	}
+}
+
+// bindNestedStruct binds the struct target, whose tags have been validated along with the tags of the target passed to
+// BindValidated.
+func bindNestedStruct(v *Validator, target reflect.Value) {
+	fields, _ := bindFields(target.Type())
+	bindStruct(v, fields, target)
+}
+
+func bindField(v *Validator, name string, opts bindOptions, target reflect.Value) {
+	val := v.config[name]
+	if val == nil && opts.defaultValue != nil {
+		val = *opts.defaultValue
+	}
+	if val == nil {
+		if opts.required {
+			v.Add(name, "is required")
+		} else if target.Kind() == reflect.Struct {
+			// Report the required fields of a missing nested object and apply its defaults.
+			bindNestedStruct(v.sub(name, nil), target)
+		}
+		return
+	}
+
+	if reason := bindValue(v, name, val, target); reason != nil {
+		v.AddError(name, reason)
+		return
+	}
+	checkConstraints(v, name, opts, target)
+}
+
+// bindValue decodes val into target. Problems of nested values are recorded with the Validator, a problem of the
+// value itself is returned.
+func bindValue(v *Validator, name string, val any, target reflect.Value) error {
+	if val == nil {
+		return nil
+	}
+	if target.Type() == durationType {
+		d, err := parseDuration(val)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(d))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(target.Type().Elem())
+		if err := bindValue(v, name, val, elem.Elem()); err != nil {
+			return err
+		}
+		target.Set(elem)
+	case reflect.Interface:
+		if !reflect.TypeOf(val).AssignableTo(target.Type()) {
+			return fmt.Errorf("cannot convert %T to %s", val, target.Type())
+		}
+		target.Set(reflect.ValueOf(val))
+	case reflect.Struct:
+		nested, ok := val.(map[string]any)
+		if !ok {
+			return errors.New("must be an object")
+		}
+		bindNestedStruct(v.sub(name, nested), target)
+	case reflect.Slice:
+		values := reflect.ValueOf(val)
+		if values.Kind() != reflect.Slice {
+			return errors.New("must be an array")
+		}
+		result := reflect.MakeSlice(target.Type(), values.Len(), values.Len())
+		for i := range values.Len() {
+			elemName := fmt.Sprintf("%s[%d]", name, i)
+			if err := bindValue(v, elemName, values.Index(i).Interface(), result.Index(i)); err != nil {
+				v.AddError(elemName, err)
+			}
+		}
+		target.Set(result)
+	case reflect.Map:
+		if target.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", target.Type())
+		}
+		if kv, ok := val.([]any); ok {
+			entries, err := ToKeyValue(map[string]any{name: kv}, name)
+			if err != nil {
+				return errors.New("must be a key/value array")
+			}
+			val = toAnyMap(entries)
+		}
+		entries, ok := val.(map[string]any)
+		if !ok {
+			return errors.New("must be an object")
+		}
+		result := reflect.MakeMapWithSize(target.Type(), len(entries))
+		for key, entry := range entries {
+			elem := reflect.New(target.Type().Elem()).Elem()
+			entryName := fmt.Sprintf("%s.%s", name, key)
+			if err := bindValue(v, entryName, entry, elem); err != nil {
+				v.AddError(entryName, err)
+				continue
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), elem)
+		}
+		target.Set(result)
+	default:
+		return convertValue(val, target)
+	}
+	return nil
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func checkConstraints(v *Validator, name string, opts bindOptions, target reflect.Value) {
+	for target.Kind() == reflect.Pointer {
+		if target.IsNil() {
+			return
+		}
+		target = target.Elem()
+	}
+
+	switch {
+	case target.Type() == durationType:
+		d := time.Duration(target.Int())
+		if opts.min != nil {
+			limit := time.Duration(opts.min.value)
+			v.Check(d >= limit, name, fmt.Sprintf("must be at least %s", limit))
+		}
+		if opts.max != nil {
+			limit := time.Duration(opts.max.value)
+			v.Check(d <= limit, name, fmt.Sprintf("must be at most %s", limit))
+		}
+	case isNumber(target.Kind()):
+		f, _ := toFloat64Strict(target.Interface())
+		checkBounds(v, name, opts, f, "be ", "")
+	case target.Kind() == reflect.String || target.Kind() == reflect.Slice || target.Kind() == reflect.Map:
+		unit := "entries"
+		if target.Kind() == reflect.String {
+			unit = "characters"
+		}
+		if opts.required && target.Len() == 0 {
+			v.Add(name, "must not be empty")
+		}
+		checkBounds(v, name, opts, float64(target.Len()), "have ", " "+unit)
+	}
+
+	if len(opts.enum) > 0 {
+		values := []reflect.Value{target}
+		if target.Kind() == reflect.Slice {
+			values = values[:0]
+			for i := range target.Len() {
+				values = append(values, target.Index(i))
+			}
+		}
+		for _, value := range values {
+			if s := fmt.Sprint(value.Interface()); !slices.Contains(opts.enum, s) {
+				v.Add(name, fmt.Sprintf("must be one of %s", strings.Join(opts.enum, ", ")))
+				break
+			}
+		}
+	}
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func checkBounds(v *Validator, name string, opts bindOptions, value float64, verb string, unit string) {
+	if opts.min != nil {
+		v.Check(value >= opts.min.value, name, fmt.Sprintf("must %sat least %s%s", verb, opts.min.text, unit))
+	}
+	if opts.max != nil {
+		v.Check(value <= opts.max.value, name, fmt.Sprintf("must %sat most %s%s", verb, opts.max.text, unit))
+	}
+}
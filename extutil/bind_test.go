@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steadybit/extension-kit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindTarget struct {
+	Port int `json:"port" config:"required,min=1,max=65535"`
+}
+
+type bindEmbedded struct {
+	Verbose bool `json:"verbose"`
+}
+
+type bindConfig struct {
+	bindEmbedded
+	Duration  time.Duration     `json:"duration" config:"required,min=1s"`
+	Workers   int               `json:"workers" config:"default=2,min=1,max=64"`
+	Load      *float64          `json:"load" config:"min=0,max=100"`
+	Mode      string            `json:"mode" config:"enum=cpu|memory"`
+	Hosts     []string          `json:"hosts" config:"max=2"`
+	Headers   map[string]string `json:"headers"`
+	Target    bindTarget        `json:"target"`
+	Raw       any               `json:"raw"`
+	Untouched string            `json:"untouched"`
+	Ignored   string            `json:"-"`
+}
+
+func TestBind(t *testing.T) {
+	config := map[string]any{
+		"verbose":  "true",
+		"duration": "30000",
+		"load":     "12.5",
+		"mode":     "cpu",
+		"hosts":    []any{"a", "b"},
+		"headers":  []any{map[string]any{"key": "k", "value": "v"}},
+		"target":   map[string]any{"port": "8080"},
+		"raw":      []any{1.0},
+		"Ignored":  "x",
+	}
+
+	result := bindConfig{Untouched: "keep", Ignored: "keep"}
+	require.NoError(t, Bind(config, &result))
+
+	assert.Equal(t, bindConfig{
+		bindEmbedded: bindEmbedded{Verbose: true},
+		Duration:     30 * time.Second,
+		Workers:      2,
+		Load:         new(12.5),
+		Mode:         "cpu",
+		Hosts:        []string{"a", "b"},
+		Headers:      map[string]string{"k": "v"},
+		Target:       bindTarget{Port: 8080},
+		Raw:          []any{1.0},
+		Untouched:    "keep",
+		Ignored:      "keep",
+	}, result)
+}
+
+func TestBindCollectsFieldErrors(t *testing.T) {
+	config := map[string]any{
+		"workers": 1.5,
+		"load":    101,
+		"mode":    "disk",
+		"hosts":   []any{"a", 1, "c"},
+		"headers": map[string]any{"k": true},
+		"target":  map[string]any{"port": 0},
+	}
+
+	var result bindConfig
+	err := Bind(config, &result)
+
+	extErr, ok := errors.AsType[*extension_kit.ExtensionError](err)
+	require.True(t, ok)
+	assert.Equal(t, "Invalid configuration", extErr.Title)
+	assert.Equal(t, 400, extErr.StatusCode())
+
+	v := NewValidator(config)
+	require.NoError(t, BindValidated(v, &result))
+	assert.ElementsMatch(t, []FieldError{
+		{Path: "duration", Reason: "is required"},
+		{Path: "workers", Reason: "1.5 is not an integer"},
+		{Path: "load", Reason: "must be at most 100"},
+		{Path: "mode", Reason: "must be one of cpu, memory"},
+		{Path: "hosts[1]", Reason: "cannot convert int to string"},
+		{Path: "hosts", Reason: "must have at most 2 entries"},
+		{Path: "headers.k", Reason: "cannot convert bool to string"},
+		{Path: "target.port", Reason: "must be at least 1"},
+	}, v.Errors())
+}
+
+func TestBindRejectsInvalidTargets(t *testing.T) {
+	assert.Error(t, Bind(map[string]any{}, bindConfig{}))
+	assert.Error(t, Bind(map[string]any{}, new(int)))
+
+	var malformed struct {
+		Value int `json:"value" config:"minimum=1"`
+	}
+	assert.EqualError(t, Bind(map[string]any{}, &malformed), "field Value: unknown config tag option \"minimum=1\"")
+
+	var malformedNested struct {
+		Nested []struct {
+			Value int `json:"value" config:"max=ten"`
+		} `json:"nested"`
+	}
+	assert.EqualError(t, Bind(map[string]any{}, &malformedNested), "field Nested: field Value: invalid max \"ten\"")
+
+	var invalidDefault struct {
+		Value time.Duration `json:"value" config:"default=soon"`
+	}
+	assert.ErrorContains(t, Bind(map[string]any{}, &invalidDefault), "field Value: invalid default \"soon\"")
+
+	var unsupportedBound struct {
+		Value bool `json:"value" config:"min=1"`
+	}
+	assert.EqualError(t, Bind(map[string]any{}, &unsupportedBound), "field Value: min is not supported for bool")
+}
+
+func TestBindChecksMissingNestedStructs(t *testing.T) {
+	var result struct {
+		Target   bindTarget  `json:"target"`
+		Optional *bindTarget `json:"optional"`
+		Nested   struct {
+			Workers int `json:"workers" config:"default=2"`
+		} `json:"nested"`
+	}
+
+	v := NewValidator(map[string]any{})
+	require.NoError(t, BindValidated(v, &result))
+	assert.Equal(t, []FieldError{{Path: "target.port", Reason: "is required"}}, v.Errors())
+	assert.Nil(t, result.Optional)
+	assert.Equal(t, 2, result.Nested.Workers)
+}
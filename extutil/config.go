@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrMissingValue is reported by Get if the config has no (or a nil) value for the key.
+var ErrMissingValue = errors.New("missing value")
+
+// ConfigValueError reports why the config value for a key could not be converted.
+type ConfigValueError struct {
+	Key string
+	Err error
+}
+
+func (e *ConfigValueError) Error() string {
+	return fmt.Sprintf("config value '%s': %v", e.Key, e.Err)
+}
+
+func (e *ConfigValueError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigValue lists the types supported by Get.
+type ConfigValue interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64 | ~bool | ~string
+}
+
+// Get returns the config value for key converted to T. In contrast to the To* functions, it fails instead of
+// returning a zero value: a missing value is reported as ErrMissingValue, and lossy conversions (a negative number to
+// an unsigned type, a fraction to an integer type, a value out of the range of T) and unparsable strings are rejected.
+// All errors are of type *ConfigValueError.
+func Get[T ConfigValue](config map[string]any, key string) (T, error) {
+	return getWith(config, key, func(val any) (T, error) {
+		var result T
+		err := convertValue(val, reflect.ValueOf(&result).Elem())
+		return result, err
+	})
+}
+
+// GetOrDefault behaves like Get, but returns defaultValue instead of ErrMissingValue.
+func GetOrDefault[T ConfigValue](config map[string]any, key string, defaultValue T) (T, error) {
+	if config[key] == nil {
+		return defaultValue, nil
+	}
+	return Get[T](config, key)
+}
+
+// GetValidated behaves like Get, but records a problem with the Validator instead of returning an error.
+func GetValidated[T ConfigValue](v *Validator, key string) T {
+	return getValidatedWith(v, key, Get[T])
+}
+
+// GetValidatedOrDefault behaves like GetOrDefault, but records a problem with the Validator instead of returning an
+// error.
+func GetValidatedOrDefault[T ConfigValue](v *Validator, key string, defaultValue T) T {
+	if v.config[key] == nil {
+		return defaultValue
+	}
+	return GetValidated[T](v, key)
+}
+
+func getWith[T any](config map[string]any, key string, convert func(val any) (T, error)) (T, error) {
+	val := config[key]
+	if val == nil {
+		var zero T
+		return zero, &ConfigValueError{Key: key, Err: ErrMissingValue}
+	}
+	result, err := convert(val)
+	if err != nil {
+		return result, &ConfigValueError{Key: key, Err: err}
+	}
+	return result, nil
+}
+
+func getValidatedWith[T any](v *Validator, key string, get func(config map[string]any, key string) (T, error)) T {
+	result, err := get(v.config, key)
+	if errors.Is(err, ErrMissingValue) {
+		v.Add(key, "is required")
+	} else if configValueErr, ok := errors.AsType[*ConfigValueError](err); ok {
+		v.AddError(key, configValueErr.Err)
+	}
+	return result
+}
+
+// convertValue converts a loosely typed config value, as found in a decoded JSON object, into target without losing
+// information.
+func convertValue(val any, target reflect.Value) error {
+	if number, ok := val.(json.Number); ok {
+		val = number.String()
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64Strict(val)
+		if err != nil {
+			return err
+		}
+		if target.OverflowInt(i) {
+			return fmt.Errorf("%v is out of range for %s", val, target.Type())
+		}
+		target.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := toUint64Strict(val)
+		if err != nil {
+			return err
+		}
+		if target.OverflowUint(u) {
+			return fmt.Errorf("%v is out of range for %s", val, target.Type())
+		}
+		target.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64Strict(val)
+		if err != nil {
+			return err
+		}
+		if target.OverflowFloat(f) {
+			return fmt.Errorf("%v is out of range for %s", val, target.Type())
+		}
+		target.SetFloat(f)
+	case reflect.Bool:
+		switch val := val.(type) {
+		case bool:
+			target.SetBool(val)
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			if err != nil {
+				return fmt.Errorf("cannot parse %q as boolean", val)
+			}
+			target.SetBool(b)
+		default:
+			return fmt.Errorf("cannot convert %T to boolean", val)
+		}
+	case reflect.String:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("cannot convert %T to string", val)
+		}
+		target.SetString(s)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+	return nil
+}
+
+func toInt64Strict(val any) (int64, error) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%v is out of range for int64", val)
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("%v is not an integer", val)
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is out of range for int64", val)
+		}
+		return int64(f), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err == nil {
+			return i, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%q is out of range for int64", val)
+		}
+		if f, floatErr := strconv.ParseFloat(s, 64); floatErr == nil {
+			return toInt64Strict(f)
+		}
+		return 0, fmt.Errorf("cannot parse %q as integer", val)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to integer", val)
+	}
+}
+
+func toUint64Strict(val any) (uint64, error) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%q is out of range for uint64", val)
+		}
+	}
+	i, err := toInt64Strict(val)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%v must not be negative", val)
+	}
+	return uint64(i), nil
+}
+
+func toFloat64Strict(val any) (float64, error) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse %q as number", val)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to number", val)
+	}
+}
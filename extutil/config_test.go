@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     any
+		want    int
+		wantErr string
+	}{
+		{name: "int", val: 1, want: 1},
+		{name: "int64", val: int64(1), want: 1},
+		{name: "uint", val: uint(1), want: 1},
+		{name: "integral float64", val: float64(10), want: 10},
+		{name: "string", val: " 10 ", want: 10},
+		{name: "json.Number", val: json.Number("10"), want: 10},
+		{name: "float string", val: "1e3", want: 1000},
+		{name: "fraction", val: 1.5, wantErr: "config value 'key': 1.5 is not an integer"},
+		{name: "duration string", val: "10s", wantErr: "config value 'key': cannot parse \"10s\" as integer"},
+		{name: "bool", val: true, wantErr: "config value 'key': cannot convert bool to integer"},
+		{name: "missing", val: nil, wantErr: "config value 'key': missing value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Get[int](map[string]any{"key": tt.val}, "key")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetRejectsLossyConversions(t *testing.T) {
+	_, err := Get[uint](map[string]any{"key": -1}, "key")
+	assert.EqualError(t, err, "config value 'key': -1 must not be negative")
+
+	_, err = Get[uint64](map[string]any{"key": "-1"}, "key")
+	assert.EqualError(t, err, "config value 'key': -1 must not be negative")
+
+	_, err = Get[int32](map[string]any{"key": int64(math.MaxInt32) + 1}, "key")
+	assert.EqualError(t, err, "config value 'key': 2147483648 is out of range for int32")
+
+	_, err = Get[int64](map[string]any{"key": "9223372036854775808"}, "key")
+	assert.EqualError(t, err, "config value 'key': \"9223372036854775808\" is out of range for int64")
+
+	_, err = Get[int64](map[string]any{"key": 1e19}, "key")
+	assert.EqualError(t, err, "config value 'key': 1e+19 is out of range for int64")
+
+	_, err = Get[float32](map[string]any{"key": 1e39}, "key")
+	assert.EqualError(t, err, "config value 'key': 1e+39 is out of range for float32")
+
+	u, err := Get[uint64](map[string]any{"key": "18446744073709551615"}, "key")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), u)
+}
+
+func TestGetBoolAndString(t *testing.T) {
+	b, err := Get[bool](map[string]any{"key": "true"}, "key")
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = Get[bool](map[string]any{"key": "yes"}, "key")
+	assert.EqualError(t, err, "config value 'key': cannot parse \"yes\" as boolean")
+
+	s, err := Get[string](map[string]any{"key": "value"}, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", s)
+
+	_, err = Get[string](map[string]any{"key": 1}, "key")
+	assert.EqualError(t, err, "config value 'key': cannot convert int to string")
+}
+
+func TestGetErrors(t *testing.T) {
+	_, err := Get[int](map[string]any{}, "duration")
+	assert.ErrorIs(t, err, ErrMissingValue)
+
+	var configValueErr *ConfigValueError
+	require.ErrorAs(t, err, &configValueErr)
+	assert.Equal(t, "duration", configValueErr.Key)
+}
+
+func TestGetOrDefault(t *testing.T) {
+	got, err := GetOrDefault(map[string]any{}, "key", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 5, got)
+
+	got, err = GetOrDefault(map[string]any{"key": "7"}, "key", 5)
+	require.NoError(t, err)
+	assert.Equal(t, 7, got)
+
+	_, err = GetOrDefault(map[string]any{"key": "x"}, "key", 5)
+	assert.Error(t, err)
+}
+
+func TestGetValidated(t *testing.T) {
+	v := NewValidator(map[string]any{"duration": "10s", "percentage": "50"})
+
+	assert.Equal(t, 0, GetValidated[int](v, "duration"))
+	assert.Equal(t, 50, GetValidated[int](v, "percentage"))
+	assert.Equal(t, 0, GetValidated[int](v, "missing"))
+	assert.Equal(t, 3, GetValidatedOrDefault(v, "optional", 3))
+
+	assert.Equal(t, []FieldError{
+		{Path: "duration", Reason: "cannot parse \"10s\" as integer"},
+		{Path: "missing", Reason: "is required"},
+	}, v.Errors())
+	assert.True(t, errors.Is(v.ToError("Invalid"), v.Errors()[0]))
+}
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Ptr returns a pointer to the given value. You will find this helpful when desiring to pass a literal value to a function that requires a pointer.
@@ -224,14 +225,15 @@ func JsonMangle[T any](in T) T {
 	return in
 }
 
+// MaskString replaces the first occurrence of search in s with '*', except for its last remaining runes.
 func MaskString(s string, search string, remaining int) string {
 	searchStringIndex := strings.Index(s, search)
 	if searchStringIndex == -1 {
 		return s
 	}
 
-	startIndex := searchStringIndex
-	stopIndex := startIndex + len(search) - remaining
+	startIndex := utf8.RuneCountInString(s[:searchStringIndex])
+	stopIndex := startIndex + utf8.RuneCountInString(search) - remaining
 
 	out := []rune(s)
 	for i := startIndex; i < stopIndex; i++ {
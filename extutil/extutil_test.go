@@ -255,6 +255,11 @@ func TestMaskString(t *testing.T) {
 			search:    "123456",
 			remaining: 10},
 			want: "command --apiKey=123456 --fast"},
+		{name: "should mask multibyte runes", args: args{
+			s:         "pässwörd=gehéim!",
+			search:    "gehéim",
+			remaining: 1},
+			want: "pässwörd=*****m!"},
 		{name: "should ignore if search not present", args: args{
 			s:         "command --fast",
 			search:    "123456",
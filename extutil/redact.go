@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSensitiveKeys are the keys whose values are masked by DefaultRedactor, see NewRedactor.
+var DefaultSensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"accesskey",
+	"privatekey",
+	"credential",
+	"authorization",
+	"kubeconfig",
+	"cookie",
+}
+
+// DefaultRedactor masks the values of DefaultSensitiveKeys.
+var DefaultRedactor = NewRedactor(DefaultSensitiveKeys)
+
+// Redactor masks secrets in JSON documents, e.g., before they are logged.
+type Redactor struct {
+	keys  []string
+	paths [][]string
+	// Remaining is the number of trailing runes of a string value that are left unmasked, see MaskString. Values that
+	// are not longer than that are masked completely.
+	Remaining int
+}
+
+// NewRedactor creates a Redactor masking the values of keys and paths.
+//
+// A key matches all object members whose names contain it, case-insensitively and ignoring '-' and '_', so that "apikey"
+// matches "x-api-key" and "apiKey". Paths address values from the root of the document, like "$.config.headers" or
+// "targets[*].attributes.token". Their segments are separated by '.' or given as index in brackets and match member
+// names exactly, '*' matches any member or array element.
+func NewRedactor(keys []string, paths ...string) *Redactor {
+	r := &Redactor{}
+	for _, key := range keys {
+		r.keys = append(r.keys, normalizeKey(key))
+	}
+	for _, path := range paths {
+		r.paths = append(r.paths, parsePath(path))
+	}
+	return r
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
+
+func parsePath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	var segments []string
+	for segment := range strings.SplitSeq(path, ".") {
+		if segment != "" {
+			segments = append(segments, strings.Trim(segment, `'"`))
+		}
+	}
+	return segments
+}
+
+// RedactJson returns the JSON document with the sensitive values masked. Data that is not a JSON document is returned
+// as it is.
+func (r *Redactor) RedactJson(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return data
+	}
+	redacted, err := json.Marshal(r.Redact(value))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+// Redact returns a copy of value, as produced by json.Unmarshal into any, with the sensitive values masked. String
+// values are masked through MaskString, all others are replaced by "*****".
+func (r *Redactor) Redact(value any) any {
+	return r.redact(value, nil)
+}
+
+func (r *Redactor) redact(value any, path []string) any {
+	if r.matchesPath(path) {
+		return r.mask(value)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, member := range v {
+			if r.MatchesKey(key) {
+				result[key] = r.mask(member)
+			} else {
+				result[key] = r.redact(member, append(path, key))
+			}
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, element := range v {
+			result[i] = r.redact(element, append(path, strconv.Itoa(i)))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// MatchesKey reports whether the values of members named key are masked, see NewRedactor. This can be used to mask
+// other named values the same way, e.g., HTTP headers.
+func (r *Redactor) MatchesKey(key string) bool {
+	normalized := normalizeKey(key)
+	for _, sensitive := range r.keys {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) matchesPath(path []string) bool {
+	for _, sensitive := range r.paths {
+		if len(sensitive) != len(path) || len(path) == 0 {
+			continue
+		}
+		matches := true
+		for i, segment := range sensitive {
+			if segment != "*" && segment != path[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) mask(value any) any {
+	if s, ok := value.(string); ok {
+		return r.Mask(s)
+	}
+	if value == nil {
+		return nil
+	}
+	return "*****"
+}
+
+// Mask masks s, except for its last Remaining runes.
+func (r *Redactor) Mask(s string) string {
+	remaining := r.Remaining
+	if remaining >= utf8.RuneCountInString(s) {
+		remaining = 0
+	}
+	return MaskString(s, s, remaining)
+}
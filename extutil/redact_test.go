@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactorRedactJson(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor *Redactor
+		data     string
+		want     string
+	}{
+		{
+			name:     "default keys",
+			redactor: DefaultRedactor,
+			data:     `{"config":{"apiToken":"abc123","X-Api-Key":"k3y","password":42,"duration":10000}}`,
+			want:     `{"config":{"X-Api-Key":"***","apiToken":"******","duration":10000,"password":"*****"}}`,
+		},
+		{
+			name:     "nested objects are masked as a whole",
+			redactor: DefaultRedactor,
+			data:     `{"kubeconfig":{"users":[{"name":"admin"}]}}`,
+			want:     `{"kubeconfig":"*****"}`,
+		},
+		{
+			name:     "arrays",
+			redactor: DefaultRedactor,
+			data:     `[{"secret":"s"},{"name":"n"}]`,
+			want:     `[{"secret":"*"},{"name":"n"}]`,
+		},
+		{
+			name:     "paths",
+			redactor: NewRedactor(nil, "$.config.headers", "targets[*].attributes.dsn"),
+			data:     `{"config":{"headers":{"X-Custom":"v"},"url":"u"},"targets":[{"attributes":{"dsn":"postgres://u:p@h","name":"db"}}]}`,
+			want:     `{"config":{"headers":"*****","url":"u"},"targets":[{"attributes":{"dsn":"****************","name":"db"}}]}`,
+		},
+		{
+			name:     "remaining runes",
+			redactor: &Redactor{keys: []string{"token"}, Remaining: 2},
+			data:     `{"token":"abcdef","shortToken":"ab"}`,
+			want:     `{"shortToken":"**","token":"****ef"}`,
+		},
+		{
+			name:     "numbers are kept exactly",
+			redactor: DefaultRedactor,
+			data:     `{"big":12345678901234567890,"secret":null}`,
+			want:     `{"big":12345678901234567890,"secret":null}`,
+		},
+		{
+			name:     "not json",
+			redactor: DefaultRedactor,
+			data:     `password=secret`,
+			want:     `password=secret`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(tt.redactor.RedactJson([]byte(tt.data))))
+		})
+	}
+}
+
+func TestRedactorMatchesKey(t *testing.T) {
+	assert.True(t, DefaultRedactor.MatchesKey("X-Auth-Token"))
+	assert.True(t, DefaultRedactor.MatchesKey("x_api_key"))
+	assert.False(t, DefaultRedactor.MatchesKey("Accept"))
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetDuration returns the config value for key as duration. Numbers (and numeric strings) are interpreted as
+// milliseconds, as sent by the agent for duration parameters, other strings are parsed by time.ParseDuration, e.g.
+// "30s" or "1m30s". Negative durations are rejected. Errors are reported like in Get.
+func GetDuration(config map[string]any, key string) (time.Duration, error) {
+	return getWith(config, key, parseDuration)
+}
+
+// GetValidatedDuration behaves like GetDuration, but records a problem with the Validator instead of returning an
+// error.
+func GetValidatedDuration(v *Validator, key string) time.Duration {
+	return getValidatedWith(v, key, GetDuration)
+}
+
+// GetPercentage returns the config value for key as percentage between 0 and 100 (both inclusive). Numbers and
+// numeric strings with an optional "%" suffix are accepted. Errors are reported like in Get.
+func GetPercentage(config map[string]any, key string) (float64, error) {
+	return getWith(config, key, parsePercentage)
+}
+
+// GetValidatedPercentage behaves like GetPercentage, but records a problem with the Validator instead of returning
+// an error.
+func GetValidatedPercentage(v *Validator, key string) float64 {
+	return getValidatedWith(v, key, GetPercentage)
+}
+
+// GetByteSize returns the config value for key as number of bytes. Numbers are interpreted as bytes, strings may
+// carry an IEC (Ki, Mi, Gi, Ti, Pi, Ei, optionally followed by "B") or SI unit (k, M, G, T, P, E, optionally followed
+// by "B"), e.g. "512Mi", "1GB" or "1.5Gi". Units are case-insensitive. Sizes that are not a whole number of bytes
+// are rejected. Errors are reported like in Get.
+func GetByteSize(config map[string]any, key string) (uint64, error) {
+	return getWith(config, key, parseByteSize)
+}
+
+// GetValidatedByteSize behaves like GetByteSize, but records a problem with the Validator instead of returning an
+// error.
+func GetValidatedByteSize(v *Validator, key string) uint64 {
+	return getValidatedWith(v, key, GetByteSize)
+}
+
+func parseDuration(val any) (time.Duration, error) {
+	if number, ok := val.(json.Number); ok {
+		val = number.String()
+	}
+
+	var millis float64
+	if s, ok := val.(string); ok {
+		s = strings.TrimSpace(s)
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return 0, fmt.Errorf("cannot parse %q as duration", val)
+			}
+			if d < 0 {
+				return 0, fmt.Errorf("%q must not be negative", val)
+			}
+			return d, nil
+		}
+		millis = f
+	} else {
+		f, err := toFloat64Strict(val)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %T to duration", val)
+		}
+		millis = f
+	}
+
+	if math.IsNaN(millis) {
+		return 0, fmt.Errorf("%v is not a number", val)
+	}
+	if millis < 0 {
+		return 0, fmt.Errorf("%v must not be negative", val)
+	}
+	nanos := millis * float64(time.Millisecond)
+	if nanos >= math.MaxInt64 {
+		return 0, fmt.Errorf("%v is out of range for a duration", val)
+	}
+	return time.Duration(nanos), nil
+}
+
+func parsePercentage(val any) (float64, error) {
+	if s, ok := val.(string); ok {
+		val = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	}
+	f, err := toFloat64Strict(val)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || f < 0 || f > 100 {
+		return 0, fmt.Errorf("%v is not between 0 and 100", f)
+	}
+	return f, nil
+}
+
+var byteSizePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([a-zA-Z]*)$`)
+
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"e":   1e18,
+	"eb":  1e18,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+	"ei":  1 << 60,
+	"eib": 1 << 60,
+}
+
+func parseByteSize(val any) (uint64, error) {
+	if number, ok := val.(json.Number); ok {
+		val = number.String()
+	}
+	s, ok := val.(string)
+	if !ok {
+		return toUint64Strict(val)
+	}
+
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("%q must not be negative", val)
+	}
+	match := byteSizePattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("cannot parse %q as byte size", val)
+	}
+	multiplier, ok := byteUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown byte size unit %q in %q", match[2], val)
+	}
+
+	size, ok := new(big.Rat).SetString(match[1])
+	if !ok {
+		return 0, fmt.Errorf("cannot parse %q as byte size", val)
+	}
+	size.Mul(size, new(big.Rat).SetInt(new(big.Int).SetUint64(multiplier)))
+	if !size.IsInt() {
+		return 0, fmt.Errorf("%q is not a whole number of bytes", val)
+	}
+	if !size.Num().IsUint64() {
+		return 0, fmt.Errorf("%q is out of range for uint64", val)
+	}
+	return size.Num().Uint64(), nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     any
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "int millis", val: 1500, want: 1500 * time.Millisecond},
+		{name: "float millis", val: float64(30000), want: 30 * time.Second},
+		{name: "string millis", val: "250", want: 250 * time.Millisecond},
+		{name: "duration string", val: "1m30s", want: 90 * time.Second},
+		{name: "zero", val: 0, want: 0},
+		{name: "negative millis", val: -1, wantErr: "config value 'key': -1 must not be negative"},
+		{name: "negative duration string", val: "-5s", wantErr: "config value 'key': \"-5s\" must not be negative"},
+		{name: "invalid string", val: "10 seconds", wantErr: "config value 'key': cannot parse \"10 seconds\" as duration"},
+		{name: "overflow", val: math.MaxFloat64, wantErr: "config value 'key': 1.7976931348623157e+308 is out of range for a duration"},
+		{name: "bool", val: true, wantErr: "config value 'key': cannot convert bool to duration"},
+		{name: "missing", val: nil, wantErr: "config value 'key': missing value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetDuration(map[string]any{"key": tt.val}, "key")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetPercentage(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     any
+		want    float64
+		wantErr string
+	}{
+		{name: "int", val: 50, want: 50},
+		{name: "float", val: 12.5, want: 12.5},
+		{name: "string", val: "100", want: 100},
+		{name: "string with percent sign", val: "75 %", want: 75},
+		{name: "zero", val: 0, want: 0},
+		{name: "above 100", val: 101, wantErr: "config value 'key': 101 is not between 0 and 100"},
+		{name: "negative", val: "-1%", wantErr: "config value 'key': -1 is not between 0 and 100"},
+		{name: "invalid string", val: "half", wantErr: "config value 'key': cannot parse \"half\" as number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetPercentage(map[string]any{"key": tt.val}, "key")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     any
+		want    uint64
+		wantErr string
+	}{
+		{name: "int", val: 1024, want: 1024},
+		{name: "float", val: float64(2048), want: 2048},
+		{name: "plain string", val: "100", want: 100},
+		{name: "bytes", val: "100B", want: 100},
+		{name: "IEC", val: "512Mi", want: 512 * 1024 * 1024},
+		{name: "IEC with B", val: "2GiB", want: 2 * 1024 * 1024 * 1024},
+		{name: "IEC fraction", val: "1.5Ki", want: 1536},
+		{name: "SI", val: "1GB", want: 1_000_000_000},
+		{name: "SI lowercase with space", val: "10 kb", want: 10_000},
+		{name: "SI fraction", val: "0.5M", want: 500_000},
+		{name: "max", val: "18446744073709551615", want: math.MaxUint64},
+		{name: "fraction of a byte", val: "0.1Ki", wantErr: "config value 'key': \"0.1Ki\" is not a whole number of bytes"},
+		{name: "unknown unit", val: "5XB", wantErr: "config value 'key': unknown byte size unit \"XB\" in \"5XB\""},
+		{name: "negative string", val: "-5Mi", wantErr: "config value 'key': \"-5Mi\" must not be negative"},
+		{name: "negative number", val: -5, wantErr: "config value 'key': -5 must not be negative"},
+		{name: "overflow", val: "16Ei", wantErr: "config value 'key': \"16Ei\" is out of range for uint64"},
+		{name: "invalid string", val: "lots", wantErr: "config value 'key': cannot parse \"lots\" as byte size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetByteSize(map[string]any{"key": tt.val}, "key")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetValidatedUnits(t *testing.T) {
+	v := NewValidator(map[string]any{"duration": "10s", "percentage": 150, "memory": "1Gi"})
+
+	assert.Equal(t, 10*time.Second, GetValidatedDuration(v, "duration"))
+	assert.Equal(t, float64(0), GetValidatedPercentage(v, "percentage"))
+	assert.Equal(t, uint64(1<<30), GetValidatedByteSize(v, "memory"))
+
+	assert.Equal(t, []FieldError{{Path: "percentage", Reason: "150 is not between 0 and 100"}}, v.Errors())
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/steadybit/extension-kit"
+)
+
+// FieldError describes a single invalid or missing field of a configuration.
+type FieldError struct {
+	// Path of the field, e.g. "headers" or "target.port".
+	Path   string
+	Reason string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Validator collects all problems of an action or discovery configuration instead of failing on the first one.
+// Use ToError to turn the collected problems into a single ExtensionError:
+//
+//	v := extutil.NewValidator(request.Config)
+//	v.Require("duration")
+//	headers := v.KeyValue("headers")
+//	v.Check(len(headers) <= 10, "headers", "must not contain more than 10 entries")
+//	if err := v.ToError("Invalid configuration"); err != nil {
+//		return nil, err
+//	}
+type Validator struct {
+	config map[string]any
+	prefix string
+	errors *[]FieldError
+}
+
+// NewValidator creates a Validator for the given configuration.
+func NewValidator(config map[string]any) *Validator {
+	return &Validator{config: config, errors: new([]FieldError{})}
+}
+
+// Nested returns a Validator for the nested configuration object under key. Problems are recorded with the key as
+// path prefix and are reported by the parent Validator. A missing nested object is treated as empty, a value of any
+// other type is recorded as problem.
+func (v *Validator) Nested(key string) *Validator {
+	nested, ok := v.config[key].(map[string]any)
+	if !ok && v.config[key] != nil {
+		v.Add(key, "must be an object")
+	}
+	return v.sub(key, nested)
+}
+
+// sub returns a Validator for config, nested under the given key, that reports to the same problems as v.
+func (v *Validator) sub(key string, config map[string]any) *Validator {
+	return &Validator{config: config, prefix: v.path(key) + ".", errors: v.errors}
+}
+
+func (v *Validator) path(key string) string {
+	return v.prefix + key
+}
+
+// Add records a problem for the given key.
+func (v *Validator) Add(key string, reason string) {
+	*v.errors = append(*v.errors, FieldError{Path: v.path(key), Reason: reason})
+}
+
+// AddError records the given error as problem for the given key. A nil error is ignored.
+func (v *Validator) AddError(key string, err error) {
+	if err != nil {
+		v.Add(key, err.Error())
+	}
+}
+
+// Check records the reason as problem for the given key if ok is false. It returns ok.
+func (v *Validator) Check(ok bool, key string, reason string) bool {
+	if !ok {
+		v.Add(key, reason)
+	}
+	return ok
+}
+
+// Require records a problem if the value for key is missing, nil or empty (see MustHaveValue). It returns whether the
+// value is present.
+func (v *Validator) Require(key string) bool {
+	val, ok := v.config[key]
+	if !ok || val == nil {
+		return v.Check(false, key, "is required")
+	}
+	kind := reflect.TypeOf(val).Kind()
+	if kind == reflect.Array || kind == reflect.Chan || kind == reflect.Map || kind == reflect.Slice || kind == reflect.String {
+		return v.Check(reflect.ValueOf(val).Len() > 0, key, "must not be empty")
+	} else if kind == reflect.Pointer {
+		return v.Check(!reflect.ValueOf(val).IsNil(), key, "is required")
+	}
+	return true
+}
+
+// KeyValue returns the key/value array for key (see ToKeyValue). A missing value yields nil, a malformed one is
+// recorded as problem.
+func (v *Validator) KeyValue(key string) map[string]string {
+	if v.config[key] == nil {
+		return nil
+	}
+	kv, err := ToKeyValue(v.config, key)
+	if err != nil {
+		v.Add(key, "must be a key/value array")
+	}
+	return kv
+}
+
+// Errors returns all recorded problems in the order they were recorded.
+func (v *Validator) Errors() []FieldError {
+	return *v.errors
+}
+
+// HasErrors reports whether any problem has been recorded.
+func (v *Validator) HasErrors() bool {
+	return len(*v.errors) > 0
+}
+
+// ToError returns nil if no problem has been recorded. Otherwise, it returns a single ExtensionError of type
+// extension_kit.ProblemTypeInvalidConfiguration with the given title. Its detail lists all problems and each problem
+// is reported in the invalid-params extension member. The FieldErrors are available through errors.As.
+func (v *Validator) ToError(title string) *extension_kit.ExtensionError {
+	if !v.HasErrors() {
+		return nil
+	}
+
+	causes := make([]error, 0, len(*v.errors))
+	reasons := make([]string, 0, len(*v.errors))
+	params := make([]extension_kit.InvalidParam, 0, len(*v.errors))
+	for _, fieldError := range *v.errors {
+		causes = append(causes, fieldError)
+		reasons = append(reasons, fieldError.Error())
+		params = append(params, extension_kit.InvalidParam{Name: fieldError.Path, Reason: fieldError.Reason})
+	}
+
+	err := extension_kit.ProblemTypeInvalidConfiguration.ToError(errors.Join(causes...)).WithInvalidParams(params...)
+	err.Title = title
+	err.Detail = new(strings.Join(reasons, "; "))
+	return &err
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/steadybit/extension-kit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorWithoutProblems(t *testing.T) {
+	v := NewValidator(map[string]any{
+		"duration": 1000,
+		"headers":  []any{map[string]any{"key": "a", "value": "b"}},
+	})
+
+	assert.True(t, v.Require("duration"))
+	assert.Equal(t, map[string]string{"a": "b"}, v.KeyValue("headers"))
+	assert.Nil(t, v.KeyValue("missing"))
+
+	assert.False(t, v.HasErrors())
+	assert.Nil(t, v.ToError("Invalid configuration"))
+}
+
+func TestValidatorCollectsAllProblems(t *testing.T) {
+	v := NewValidator(map[string]any{
+		"name":    "",
+		"headers": "oops",
+		"target":  map[string]any{"port": 0},
+	})
+
+	v.Require("duration")
+	v.Require("name")
+	v.KeyValue("headers")
+	target := v.Nested("target")
+	target.Require("host")
+	target.Check(ToInt(target.config["port"]) > 0, "port", "must be positive")
+	v.Nested("missing").Require("value")
+
+	assert.Equal(t, []FieldError{
+		{Path: "duration", Reason: "is required"},
+		{Path: "name", Reason: "must not be empty"},
+		{Path: "headers", Reason: "must be a key/value array"},
+		{Path: "target.host", Reason: "is required"},
+		{Path: "target.port", Reason: "must be positive"},
+		{Path: "missing.value", Reason: "is required"},
+	}, v.Errors())
+
+	err := v.ToError("Invalid configuration")
+	require.NotNil(t, err)
+	assert.Equal(t, "Invalid configuration", err.Title)
+	assert.Equal(t, "duration: is required; name: must not be empty; headers: must be a key/value array; target.host: is required; target.port: must be positive; missing.value: is required", *err.Detail)
+	assert.Equal(t, 400, err.StatusCode())
+	assert.Len(t, err.Extensions()[extension_kit.MemberInvalidParams], 6)
+
+	var fieldError FieldError
+	assert.True(t, errors.As(err, &fieldError))
+	assert.Equal(t, "duration", fieldError.Path)
+}
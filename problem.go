@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extension_kit
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Names of well-known RFC 9457 extension members.
+const (
+	// MemberInvalidParams lists the invalid parameters of a request, see InvalidParam.
+	MemberInvalidParams = "invalid-params"
+	// MemberRetryAfter holds the number of seconds after which the request may be retried.
+	MemberRetryAfter = "retry-after"
+	// MemberTraceId holds the trace ID of the failed request.
+	MemberTraceId = "traceId"
+)
+
+// reservedMembers are the members defined by RFC 9457 itself. Extension members must not shadow them.
+var reservedMembers = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+// InvalidParam describes a single invalid parameter, serialized as entry of the MemberInvalidParams member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// WithExtension returns a copy of the error with the given extension member. Members named like one of the
+// standard problem members (type, title, status, detail, instance) are ignored when serializing.
+func (e ExtensionError) WithExtension(name string, value any) ExtensionError {
+	extensions := e.Extensions()
+	if extensions == nil {
+		extensions = make(map[string]any, 1)
+	}
+	extensions[name] = value
+	e.extensions = &extensions
+	return e
+}
+
+// Extensions returns a copy of the extension members of the error, nil if it has none.
+func (e ExtensionError) Extensions() map[string]any {
+	if e.extensions == nil {
+		return nil
+	}
+	return maps.Clone(*e.extensions)
+}
+
+// Extension returns the extension member with the given name.
+func (e ExtensionError) Extension(name string) (any, bool) {
+	if e.extensions == nil {
+		return nil, false
+	}
+	value, ok := (*e.extensions)[name]
+	return value, ok
+}
+
+// WithInvalidParams returns a copy of the error listing the given parameters in the MemberInvalidParams member.
+func (e ExtensionError) WithInvalidParams(params ...InvalidParam) ExtensionError {
+	return e.WithExtension(MemberInvalidParams, params)
+}
+
+// WithRetryAfter returns a copy of the error with the MemberRetryAfter member set to the given duration, rounded up
+// to full seconds.
+func (e ExtensionError) WithRetryAfter(d time.Duration) ExtensionError {
+	return e.WithExtension(MemberRetryAfter, int64(math.Ceil(d.Seconds())))
+}
+
+// WithTraceId returns a copy of the error with the MemberTraceId member set.
+func (e ExtensionError) WithTraceId(traceId string) ExtensionError {
+	return e.WithExtension(MemberTraceId, traceId)
+}
+
+type extensionErrorMembers ExtensionError
+
+// MarshalJSON writes the standard problem members followed by the extension members in lexical order. Without
+// extension members the output is the same as for the plain struct.
+func (e ExtensionError) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(extensionErrorMembers(e))
+	if err != nil || e.extensions == nil || len(*e.extensions) == 0 {
+		return b, err
+	}
+
+	buf := bytes.NewBuffer(b[:len(b)-1])
+	for _, name := range slices.Sorted(maps.Keys(*e.extensions)) {
+		if reservedMembers[name] {
+			continue
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal((*e.extensions)[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads the standard problem members and collects all other members as extension members.
+func (e *ExtensionError) UnmarshalJSON(data []byte) error {
+	var members extensionErrorMembers
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	var extensions map[string]any
+	for name, value := range all {
+		if !reservedMembers[name] {
+			if extensions == nil {
+				extensions = make(map[string]any)
+			}
+			extensions[name] = value
+		}
+	}
+	if extensions != nil {
+		members.extensions = &extensions
+	}
+
+	*e = ExtensionError(members)
+	return nil
+}
+
+// ProblemType is a well-known problem type. Its URI is used as ExtensionError.Type, and its title and status are
+// used as defaults for errors of that type.
+type ProblemType struct {
+	URI    string
+	Title  string
+	Status int
+}
+
+var problemTypes = sync.Map{}
+
+// Well-known problem types for common extension failures.
+var (
+	ProblemTypeInvalidConfiguration  = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:invalid-configuration", Title: "Invalid configuration", Status: http.StatusBadRequest})
+	ProblemTypeTargetNotFound        = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:target-not-found", Title: "Target not found", Status: http.StatusNotFound})
+	ProblemTypeConflict              = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:conflict", Title: "Conflict", Status: http.StatusConflict})
+	ProblemTypeDependencyUnavailable = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:dependency-unavailable", Title: "Dependency unavailable", Status: http.StatusServiceUnavailable})
+	ProblemTypeTimeout               = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:timeout", Title: "Timeout", Status: http.StatusGatewayTimeout})
+	ProblemTypeInternal              = RegisterProblemType(ProblemType{URI: "urn:steadybit:problem:internal", Title: "Internal error", Status: http.StatusInternalServerError})
+)
+
+// RegisterProblemType registers a problem type so that errors with its URI as type get its status by default. An
+// already registered type with the same URI is replaced.
+func RegisterProblemType(problemType ProblemType) ProblemType {
+	problemTypes.Store(problemType.URI, problemType)
+	return problemType
+}
+
+// LookupProblemType returns the registered problem type for the given URI.
+func LookupProblemType(uri string) (ProblemType, bool) {
+	problemType, ok := problemTypes.Load(uri)
+	if !ok {
+		return ProblemType{}, false
+	}
+	return problemType.(ProblemType), true
+}
+
+// ToError converts an error to an ExtensionError of this problem type.
+func (p ProblemType) ToError(err error) ExtensionError {
+	extErr := ToError(p.Title, err)
+	extErr.Type = new(p.URI)
+	extErr.Status = p.Status
+	return extErr
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2026 Steadybit GmbH
+
+package extension_kit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalExtensionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ExtensionError
+		want string
+	}{
+		{
+			name: "without extensions",
+			err:  ToError("some title", errors.New("some error")),
+			want: `{"detail":"some error","title":"some title"}`,
+		},
+		{
+			name: "with extensions",
+			err: ToError("some title", nil).
+				WithTraceId("abc").
+				WithRetryAfter(1500 * time.Millisecond).
+				WithInvalidParams(InvalidParam{Name: "duration", Reason: "must be positive"}),
+			want: `{"title":"some title","invalid-params":[{"name":"duration","reason":"must be positive"}],"retry-after":2,"traceId":"abc"}`,
+		},
+		{
+			name: "reserved members are ignored",
+			err:  ToError("some title", nil).WithExtension("title", "other").WithExtension("status", 400),
+			want: `{"title":"some title"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.err)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestUnmarshalExtensionError(t *testing.T) {
+	var got ExtensionError
+	err := json.Unmarshal([]byte(`{"title":"some title","type":"urn:test","traceId":"abc","retry-after":2}`), &got)
+	require.NoError(t, err)
+
+	assert.Equal(t, "some title", got.Title)
+	assert.Equal(t, "urn:test", *got.Type)
+	assert.Equal(t, map[string]any{"traceId": "abc", "retry-after": float64(2)}, got.Extensions())
+}
+
+func TestWithExtensionDoesNotModifyOriginal(t *testing.T) {
+	original := ToError("some title", nil).WithTraceId("abc")
+	modified := original.WithTraceId("def")
+
+	assert.Equal(t, "abc", original.Extensions()[MemberTraceId])
+	assert.Equal(t, "def", modified.Extensions()[MemberTraceId])
+}
+
+func TestExtensionErrorIsComparable(t *testing.T) {
+	target := ToError("some title", nil).WithTraceId("abc")
+	err := fmt.Errorf("wrapped: %w", target)
+
+	assert.True(t, errors.Is(err, target))
+	traceId, ok := target.Extension(MemberTraceId)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", traceId)
+}
+
+func TestProblemType(t *testing.T) {
+	err := ProblemTypeTargetNotFound.ToError(errors.New("container gone"))
+	assert.Equal(t, "Target not found", err.Title)
+	assert.Equal(t, "urn:steadybit:problem:target-not-found", *err.Type)
+	assert.Equal(t, 404, err.StatusCode())
+
+	registered, ok := LookupProblemType("urn:steadybit:problem:timeout")
+	assert.True(t, ok)
+	assert.Equal(t, ProblemTypeTimeout, registered)
+
+	_, ok = LookupProblemType("urn:unknown")
+	assert.False(t, ok)
+
+	// The status of a registered type is used when the error itself has none.
+	assert.Equal(t, 409, ExtensionError{Title: "t", Type: new(ProblemTypeConflict.URI)}.StatusCode())
+	assert.Equal(t, 500, ExtensionError{Title: "t", Type: new("urn:unknown")}.StatusCode())
+}